@@ -1,56 +1,221 @@
 package app
 
 import (
+	"context"
+	"log"
 	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/avran02/authentication/internal/config"
 	"github.com/avran02/authentication/internal/controller"
+	"github.com/avran02/authentication/internal/eventsink"
+	"github.com/avran02/authentication/internal/health"
+	"github.com/avran02/authentication/internal/metrics"
+	"github.com/avran02/authentication/internal/pkg/hasher"
 	"github.com/avran02/authentication/internal/pkg/jwt"
 	"github.com/avran02/authentication/internal/repo"
 	"github.com/avran02/authentication/internal/server"
 	"github.com/avran02/authentication/internal/service"
+	"github.com/avran02/authentication/internal/tracing"
+	"github.com/avran02/authentication/internal/webhook"
 	"github.com/avran02/authentication/logger"
 )
 
+// activeSessionsTimeout bounds the session count query run on every metrics scrape.
+const activeSessionsTimeout = 5 * time.Second
+
+const tracingShutdownTimeout = 5 * time.Second
+
+// migrationTimeout bounds the migrations applied on startup, like building indexes of large collections.
+const migrationTimeout = 10 * time.Minute
+
 type App struct {
-	server     *server.Server
+	server *server.Server
+	// config is the configuration the app started with, see reload for the parts that change.
 	config     *config.Config
+	flags      *config.Flags
 	controller controller.Controller
+	service    service.Service
+	repo       repo.Repo
+	jwt        jwt.Generator
+	hasher     hasher.Hasher
+	webhooks   *webhook.Dispatcher
+	sink       eventsink.Sink
+	// shutdownTracing flushes the pending spans.
+	shutdownTracing func(context.Context) error
+	// stopBatches cancels the outbox batch in progress.
+	stopBatches context.CancelFunc
+	// stopBackground stops the periodic jobs, background waits for them.
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
 }
 
 func (app *App) Run() {
-	app.server.Run(app.config.Server)
+	ctx, stopBackground := context.WithCancel(context.Background())
+	app.stopBackground = stopBackground
+	batchCtx, stopBatches := context.WithCancel(context.Background())
+	app.stopBatches = stopBatches
+	serverErrs := app.server.Run(app.config.Server)
+	app.background.Add(3) //nolint:mnd
+	go func() {
+		defer app.background.Done()
+		app.purgeDeletedUsers(ctx)
+	}()
+	go func() {
+		defer app.background.Done()
+		app.dispatchOutbox(ctx, batchCtx)
+	}()
+	go func() {
+		defer app.background.Done()
+		app.watchConfig(ctx)
+	}()
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
-	sig := <-signals
-	slog.Info("shutdown server", "signal", sig.String())
-	os.Exit(0)
+	exitCode := 0
+	select {
+	case sig := <-signals:
+		slog.Info("shutdown server", "signal", sig.String())
+	case err := <-serverErrs:
+		slog.Error("server failed, shutting down", "error", err.Error())
+		exitCode = 1
+	}
+	go func() {
+		sig := <-signals
+		slog.Warn("forced shutdown", "signal", sig.String())
+		os.Exit(1)
+	}()
+
+	app.shutdown()
+	os.Exit(exitCode)
 }
 
-func New() *App {
-	config := config.New()
+// shutdown fails readiness checks for the shutdown delay, drains the servers within the shutdown
+// timeout, then stops the background work, closes the event sink, flushes the pending spans and
+// disconnects from MongoDB.
+func (app *App) shutdown() {
+	app.server.SetShuttingDown()
+	slog.Info("waiting for load balancers to notice the shutdown", "delay", app.config.Server.ShutdownDelay)
+	time.Sleep(app.config.Server.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.Server.ShutdownTimeout)
+	defer cancel()
+	if err := app.server.Shutdown(ctx); err != nil {
+		slog.Error("failed to drain servers", "error", err.Error())
+	}
+
+	app.stopBackground()
+	// the outbox batch in progress may run until the shutdown deadline, then its events are released
+	stopBatches := context.AfterFunc(ctx, app.stopBatches)
+	defer stopBatches()
+	// cancels the webhook deliveries in flight, their events are released back to the outbox
+	app.webhooks.Stop()
+	app.background.Wait()
+	if err := app.sink.Close(); err != nil {
+		slog.Error("failed to close event sink", "error", err.Error())
+	}
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancelFlush()
+	if err := app.shutdownTracing(flushCtx); err != nil {
+		slog.Error("failed to flush spans", "error", err.Error())
+	}
+	if err := app.repo.Close(flushCtx); err != nil {
+		slog.Error("failed to close repo", "error", err.Error())
+	}
+	slog.Info("shutdown complete")
+}
+
+// New builds the app from config. flags are used to load the configuration again on reload.
+func New(config *config.Config, flags *config.Flags) *App {
 	logger.Setup(config.Server)
-	slog.Debug("config loaded", "config", config)
+	slog.Debug("config loaded", "config", config.Redacted())
 	debug := false
 	if strings.ToLower(config.Server.LogLevel) == "debug" {
 		debug = true
 	}
 
+	shutdownTracing, err := tracing.Setup(config.Tracing)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %s", err)
+	}
+
 	repo := repo.New(&config.DB)
-	JWTGenerator := jwt.NewJwtGenerator(config.JWT)
-	service := service.New(repo, JWTGenerator)
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancelMigrate()
+	if _, err = repo.MigrateUp(migrateCtx, 0); err != nil {
+		log.Fatalf("failed to migrate the database: %s", err)
+	}
+	JWTGenerator, err := jwt.NewJwtGenerator(config.JWT)
+	if err != nil {
+		log.Fatalf("failed to load signing keys: %s", err)
+	}
+	passwordHasher := hasher.New(config.Hasher)
+	webhooks := webhook.New(repo, config.Webhooks)
+	sink, err := eventsink.New(config.Outbox, webhooks)
+	if err != nil {
+		log.Fatalf("failed to create event sink: %s", err)
+	}
+	service := service.New(webhookEndpointsRepo{repo, webhooks}, JWTGenerator, passwordHasher,
+		config.Login, config.PasswordPolicy, config.AccountDeletion)
 	controller := controller.New(service, config.Cookie)
-	server := server.New(controller, debug, config.CORS)
+	checker := health.NewChecker()
+	checker.Register("mongo", repo.Ping)
+	checker.Register("jwt", func(context.Context) error {
+		return JWTGenerator.CheckKeys()
+	})
+	server := server.New(controller, checker, debug, config.CORS)
+	metrics.RegisterActiveSessions(func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), activeSessionsTimeout)
+		defer cancel()
+		count, err := repo.CountSessions(ctx)
+		if err != nil {
+			slog.Error("failed to count active sessions", "error", err.Error())
+		}
+		return float64(count)
+	})
 
 	return &App{
 		config:     config,
+		flags:      flags,
 		controller: controller,
 		server:     server,
+		service:    service,
+		repo:       repo,
+		jwt:        JWTGenerator,
+		hasher:     passwordHasher,
+		webhooks:   webhooks,
+		sink:       sink,
+
+		shutdownTracing: shutdownTracing,
+	}
+}
+
+// purgeDeletedUsers periodically removes accounts whose deletion grace period is over, until ctx is done.
+func (app *App) purgeDeletedUsers(ctx context.Context) {
+	ticker := time.NewTicker(app.config.AccountDeletion.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := app.service.PurgeDeletedUsers(ctx)
+		if err != nil {
+			slog.Error("failed to purge deleted users", "error", err.Error())
+			continue
+		}
+		if purged > 0 {
+			slog.Info("purged deleted users", "count", purged)
+		}
 	}
 }
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/webhook"
+)
+
+// outboxReleaseTimeout bounds releasing the claimed events of a batch interrupted by the shutdown.
+const outboxReleaseTimeout = 5 * time.Second
+
+// dispatchOutbox publishes outbox events through the configured sink until ctx is done. An event is
+// marked as published only after the sink accepted it, so a crash in between publishes it again.
+// Batches run with batchCtx, which outlives ctx until the shutdown deadline.
+func (app *App) dispatchOutbox(ctx, batchCtx context.Context) {
+	ticker := time.NewTicker(app.config.Outbox.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// keep going while batches are full to catch up with a backlog.
+		// A started batch is finished on shutdown, its events are already claimed.
+		for ctx.Err() == nil {
+			if app.publishOutboxBatch(batchCtx) < app.config.Outbox.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+func (app *App) publishOutboxBatch(ctx context.Context) int {
+	conf := app.config.Outbox
+	records, err := app.repo.ClaimOutboxEvents(ctx, conf.BatchSize, conf.Lease)
+	if err != nil {
+		slog.Error("failed to claim outbox events", "error", err.Error())
+	}
+
+	for i, record := range records {
+		if ctx.Err() != nil {
+			app.releaseOutboxEvents(ctx, records[i:])
+			break
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, conf.Lease)
+		err := app.sink.Publish(publishCtx, record.Event, record.Attempts+1)
+		cancel()
+
+		// interrupted by the shutdown, that's not a failed attempt
+		if err != nil && (ctx.Err() != nil || errors.Is(err, webhook.ErrStopped)) {
+			app.releaseOutboxEvents(ctx, records[i:])
+			break
+		}
+		if err != nil {
+			retryAt := time.Now().UTC().Add(outboxBackoff(record.Attempts+1, conf.MaxBackoff))
+			slog.Warn("failed to publish outbox event",
+				"id", record.ID, "type", record.Event.Type, "attempt", record.Attempts+1, "retryAt", retryAt, "error", err.Error())
+			if err = app.repo.MarkOutboxEventFailed(ctx, record.ID, err.Error(), retryAt); err != nil {
+				slog.Error("failed to reschedule outbox event", "id", record.ID, "error", err.Error())
+			}
+			continue
+		}
+
+		if err = app.repo.MarkOutboxEventPublished(ctx, record.ID); err != nil {
+			slog.Error("failed to mark outbox event as published", "id", record.ID, "error", err.Error())
+		}
+	}
+	return len(records)
+}
+
+// releaseOutboxEvents makes the claimed records available again right away instead of after the lease.
+func (app *App) releaseOutboxEvents(ctx context.Context, records []models.OutboxRecord) {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), outboxReleaseTimeout)
+	defer cancel()
+	if err := app.repo.ReleaseOutboxEvents(ctx, ids); err != nil {
+		slog.Error("failed to release outbox events, they are published after the lease", "count", len(ids), "error", err.Error())
+		return
+	}
+	slog.Info("released outbox events on shutdown", "count", len(ids))
+}
+
+// outboxBackoff doubles the delay from one second per failed attempt up to maxBackoff.
+func outboxBackoff(attempt int, maxBackoff time.Duration) time.Duration {
+	delay := time.Second
+	for range attempt - 1 {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the events of a single save, editors often write a file several times.
+const reloadDebounce = 500 * time.Millisecond
+
+// watchConfig reloads the configuration on SIGHUP and when the config or keys file changes, until ctx is done.
+func (app *App) watchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Error("can't watch config files, reload with SIGHUP", "error", err.Error())
+	} else {
+		defer watcher.Close()
+	}
+	files := app.watchFiles(watcher, app.config)
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("reloading config", "trigger", "SIGHUP")
+			files = app.watchFiles(watcher, app.reload())
+		case event := <-watcherEvents(watcher):
+			if changed(event, files) {
+				debounce.Reset(reloadDebounce)
+			}
+		case err := <-watcherErrors(watcher):
+			slog.Error("config watcher failed", "error", err.Error())
+		case <-debounce.C:
+			slog.Info("reloading config", "trigger", "file change")
+			files = app.watchFiles(watcher, app.reload())
+		}
+	}
+}
+
+// watchFiles watches the directories of the config and keys files of conf, so files replaced
+// by a rename, like Kubernetes does with mounted ConfigMaps and Secrets, are noticed too.
+func (app *App) watchFiles(watcher *fsnotify.Watcher, conf *config.Config) map[string]bool {
+	files := map[string]bool{filepath.Clean(app.flags.ConfigFile()): true}
+	if conf.JWT.KeysFile != "" {
+		files[filepath.Clean(conf.JWT.KeysFile)] = true
+	}
+	if watcher == nil {
+		return files
+	}
+	for file := range files {
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			slog.Error("can't watch config file", "file", file, "error", err.Error())
+		}
+	}
+	return files
+}
+
+func changed(event fsnotify.Event, files map[string]bool) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	// Kubernetes swaps the ..data symlink to update all files of a volume at once
+	return files[filepath.Clean(event.Name)] || filepath.Base(event.Name) == "..data"
+}
+
+// watcherEvents and watcherErrors return nil channels, which never receive, without a watcher.
+func watcherEvents(watcher *fsnotify.Watcher) chan fsnotify.Event {
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Events
+}
+
+func watcherErrors(watcher *fsnotify.Watcher) chan error {
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Errors
+}
+
+// reload loads the configuration again and swaps the signing keys, token lifetimes, password
+// hashing, login settings, password policy, CORS and cookie settings. Other changes need a
+// restart. If the new configuration is invalid nothing changes. It returns the configuration in effect.
+func (app *App) reload() *config.Config {
+	conf, err := config.Load(app.flags)
+	if err != nil {
+		slog.Error("invalid config, keeping the current one", "error", err.Error())
+		return app.config
+	}
+	if err = app.jwt.Reload(conf.JWT); err != nil {
+		slog.Error("can't load signing keys, keeping the current config", "error", err.Error())
+		return app.config
+	}
+	app.hasher.Reload(conf.Hasher)
+	app.service.Reload(conf.Login, conf.PasswordPolicy)
+	app.server.ReloadCORS(conf.CORS)
+	app.controller.ReloadCookieConfig(conf.Cookie)
+
+	for name, sections := range map[string][2]any{
+		"server":           {app.config.Server, conf.Server},
+		"db":               {app.config.DB, conf.DB},
+		"account_deletion": {app.config.AccountDeletion, conf.AccountDeletion},
+		"webhooks":         {app.config.Webhooks, conf.Webhooks},
+		"outbox":           {app.config.Outbox, conf.Outbox},
+		"tracing":          {app.config.Tracing, conf.Tracing},
+	} {
+		if !reflect.DeepEqual(sections[0], sections[1]) {
+			slog.Warn("config section changed, restart to apply it", "section", name)
+		}
+	}
+	slog.Info("config reloaded")
+	return conf
+}
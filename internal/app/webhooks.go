@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/internal/webhook"
+)
+
+// webhookEndpointsRepo drops the endpoints cached by the dispatcher when the admin API changes them.
+type webhookEndpointsRepo struct {
+	repo.Repo
+	webhooks *webhook.Dispatcher
+}
+
+func (r webhookEndpointsRepo) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	defer r.webhooks.InvalidateEndpoints()
+	return r.Repo.CreateWebhookEndpoint(ctx, endpoint)
+}
+
+func (r webhookEndpointsRepo) DeleteWebhookEndpoint(ctx context.Context, endpointID string) error {
+	defer r.webhooks.InvalidateEndpoints()
+	return r.Repo.DeleteWebhookEndpoint(ctx, endpointID)
+}
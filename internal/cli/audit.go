@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/auditchain"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/logger"
+)
+
+func audit(args []string) {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, "Usage: auth-service audit verify")
+		os.Exit(2) //nolint:mnd
+	}
+	fs := flag.NewFlagSet("audit verify", flag.ExitOnError)
+	flags := config.BindFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	conf := loadConfig(flags)
+	logger.Setup(conf.Server)
+	r := repo.New(&conf.DB)
+	ctx := context.Background()
+
+	// the head is read first, events appended while the chain is walked are past it
+	headSeq, headHash, err := r.AuditChainHead(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var verifier auditchain.Verifier
+	verifier.ExpectHead(headSeq, headHash)
+	err = r.IterateAuditChain(ctx, func(event models.AuditEvent) error {
+		return verifier.Next(event)
+	})
+	if err == nil {
+		err = verifier.Finish()
+	}
+
+	var broken *auditchain.BrokenLinkError
+	if errors.As(err, &broken) {
+		fmt.Println(broken)
+		fmt.Printf("%d events verified before the break\n", verifier.Checked())
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	unchained, err := r.CountUnchainedAuditEvents(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("audit chain is intact: %d events verified\n", verifier.Checked())
+	if head := verifier.Head(); head != nil {
+		fmt.Printf("head: seq %d, hash %s\n", head.Seq, head.Hash)
+	}
+	if unchained > 0 {
+		fmt.Printf("%d events written before chaining were not verified\n", unchained)
+	}
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/avran02/authentication/internal/app"
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/hasher"
+	"github.com/avran02/authentication/internal/pkg/jwt"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/internal/service"
+)
+
+const usage = `Usage: auth-service <command> [flags]
+
+Commands:
+  serve     start the HTTP and gRPC servers (default)
+  migrate   apply, revert or list database migrations ("migrate up|down|status")
+  user      manage users ("user create|disable|reset-password")
+  sessions  revoke the sessions of a user ("sessions revoke -user <user>")
+  keys      manage the JWT signing keys ("keys generate|rotate|list")
+  import    import users with their password hashes from a JSONL or CSV file
+  audit     verify the hash chain of the audit trail ("audit verify")
+  config    check or print the effective configuration ("config validate|print")
+
+Every command accepts -config <file> and a flag per configuration key, e.g. -server.http_port.
+Run "auth-service <command> -h" to list them.
+`
+
+func Run(args []string) {
+	if len(args) == 0 {
+		serve(nil)
+		return
+	}
+
+	switch args[0] {
+	case "serve":
+		serve(args[1:])
+	case "migrate":
+		migrate(args[1:])
+	case "user":
+		user(args[1:])
+	case "sessions":
+		sessions(args[1:])
+	case "keys":
+		keys(args[1:])
+	case "config":
+		configCommand(args[1:])
+	case "import":
+		importUsers(args[1:])
+	case "audit":
+		audit(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		os.Exit(2) //nolint:mnd
+	}
+}
+
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags := config.BindFlags(fs)
+	_ = fs.Parse(args)
+
+	app.New(loadConfig(flags), flags).Run()
+}
+
+// loadConfig exits listing the problems if the configuration is invalid.
+func loadConfig(flags *config.Flags) *config.Config {
+	conf, err := config.Load(flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+	return conf
+}
+
+// newService connects to the database and builds the service the server uses.
+func newService(conf *config.Config) (service.Service, repo.Repo) {
+	generator, err := jwt.NewJwtGenerator(conf.JWT)
+	if err != nil {
+		log.Fatalf("failed to load signing keys: %s", err)
+	}
+	r := repo.New(&conf.DB)
+	return service.New(r, generator, hasher.New(conf.Hasher), conf.Login, conf.PasswordPolicy, conf.AccountDeletion), r
+}
+
+// findUser looks the user up by ID, then by username.
+func findUser(ctx context.Context, r repo.Repo, ref string) *models.User {
+	u, err := r.FindUserByID(ctx, ref)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		u, err = r.FindUserByUsername(ctx, ref)
+	}
+	if err != nil {
+		log.Fatalf("can't find user %q: %s", ref, err)
+	}
+	return u
+}
+
+// usageError prints the usage of a command and exits.
+func usageError(usage string) {
+	fmt.Fprintln(os.Stderr, "Usage: "+usage)
+	os.Exit(2) //nolint:mnd
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/pkg/jwt"
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = "auth-service config validate|print [flags]"
+
+func configCommand(args []string) {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "validate") {
+		usageError(configUsage)
+	}
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	flags := config.BindFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	conf := loadConfig(flags)
+	if args[0] == "validate" {
+		if _, err := jwt.LoadKeys(conf.JWT); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration is valid")
+		return
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2) //nolint:mnd
+	if err := enc.Encode(conf.Redacted()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/importer"
+	"github.com/avran02/authentication/internal/pkg/hasher"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/logger"
+)
+
+func importUsers(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "input format: jsonl or csv (default: by file extension)")
+	signerKey := fs.String("firebase-signer-key", "", "base64 signer key of the Firebase project")
+	saltSeparator := fs.String("firebase-salt-separator", "Bw==", "base64 salt separator of the Firebase project")
+	rounds := fs.Int("firebase-rounds", 8, "scrypt rounds of the Firebase project")          //nolint:mnd
+	memCost := fs.Int("firebase-mem-cost", 14, "scrypt memory cost of the Firebase project") //nolint:mnd
+	flags := config.BindFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: auth-service import [flags] <file|->")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2) //nolint:mnd
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	firebase := hasher.FirebaseScryptParams{Rounds: *rounds, MemCost: *memCost}
+	var err error
+	if firebase.SignerKey, err = base64.StdEncoding.DecodeString(*signerKey); err != nil {
+		log.Fatalf("bad firebase signer key: %s", err)
+	}
+	if firebase.SaltSeparator, err = base64.StdEncoding.DecodeString(*saltSeparator); err != nil {
+		log.Fatalf("bad firebase salt separator: %s", err)
+	}
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("can't open %s: %s", path, err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	conf := loadConfig(flags)
+	logger.Setup(conf.Server)
+
+	res, err := importer.New(repo.New(&conf.DB)).Import(context.Background(), input, importer.Options{
+		Format:   *format,
+		Firebase: firebase,
+	})
+	slog.Info("import finished", "imported", res.Imported, "skipped", res.Skipped, "failed", res.Failed)
+	if err != nil {
+		log.Fatal(err) //nolint:gocritic
+	}
+}
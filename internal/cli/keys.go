@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/pkg/jwt"
+)
+
+const keysUsage = `auth-service keys generate
+       auth-service keys rotate [-keep N] [flags]
+       auth-service keys list [flags]`
+
+// keyIDLayout names the keys by their creation time.
+const keyIDLayout = "20060102T150405Z"
+
+func keys(args []string) {
+	if len(args) == 0 {
+		usageError(keysUsage)
+	}
+	if args[0] == "generate" {
+		fmt.Println(generateSecret())
+		return
+	}
+
+	flagSet := flag.NewFlagSet("keys "+args[0], flag.ExitOnError)
+	keep := flagSet.Int("keep", 2, "number of previous keys kept to verify tokens signed before the rotation") //nolint:mnd
+	flags := config.BindFlags(flagSet)
+	_ = flagSet.Parse(args[1:])
+	conf := loadConfig(flags)
+
+	switch args[0] {
+	case "rotate":
+		id, err := rotateKeys(conf.JWT, *keep)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("key %s is active, running servers pick it up from %s\n", id, conf.JWT.KeysFile)
+	case "list":
+		set, err := jwt.LoadKeys(conf.JWT)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, key := range set.Keys {
+			active := ""
+			if key.ID == set.Active {
+				active = "active"
+			}
+			created := "-"
+			if !key.CreatedAt.IsZero() {
+				created = key.CreatedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-20s  %-25s  %s\n", key.ID, created, active)
+		}
+	default:
+		usageError(keysUsage)
+	}
+}
+
+// generateSecret returns a random secret long enough for jwt.secret.
+func generateSecret() string {
+	secret := make([]byte, config.MinJWTSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(secret)
+}
+
+// rotateKeys adds a new active key to the keys file and drops the oldest inactive keys beyond keep.
+// Without a keys file yet, the set starts with jwt.secret so tokens it signed stay valid.
+func rotateKeys(conf config.JWT, keep int) (string, error) {
+	if conf.KeysFile == "" {
+		return "", errors.New("jwt.keys_file must be set to rotate keys")
+	}
+	set, err := jwt.LoadKeys(conf)
+	if errors.Is(err, fs.ErrNotExist) {
+		set, err = jwt.LoadKeys(config.JWT{Secret: conf.Secret})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	key := jwt.Key{ID: now.Format(keyIDLayout), Secret: generateSecret(), CreatedAt: now}
+	if slices.ContainsFunc(set.Keys, func(k jwt.Key) bool { return k.ID == key.ID }) {
+		return "", fmt.Errorf("key %s already exists, retry in a second", key.ID)
+	}
+	set.Keys = append(set.Keys, key)
+	set.Active = key.ID
+	slices.SortStableFunc(set.Keys, func(a, b jwt.Key) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	if len(set.Keys) > keep+1 {
+		set.Keys = set.Keys[:keep+1]
+	}
+
+	if err = set.Validate(); err != nil {
+		return "", err
+	}
+	if err = set.Save(conf.KeysFile); err != nil {
+		return "", err
+	}
+	return key.ID, nil
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/logger"
+)
+
+const migrateUsage = "auth-service migrate up|down|status [flags]"
+
+func migrate(args []string) {
+	if len(args) == 0 {
+		usageError(migrateUsage)
+	}
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	to := fs.Int("to", -1, "target version: up applies all by default, down reverts the latest one by default")
+	flags := config.BindFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	conf := loadConfig(flags)
+	logger.Setup(conf.Server)
+	r := repo.New(&conf.DB)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := r.MigrateUp(ctx, max(*to, 0))
+		for _, m := range applied {
+			fmt.Printf("applied %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+	case "down":
+		target := *to
+		if target < 0 {
+			target = latestApplied(ctx, r) - 1
+		}
+		reverted, err := r.MigrateDown(ctx, max(target, 0))
+		for _, m := range reverted {
+			fmt.Printf("reverted %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		status, err := r.MigrationStatus(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range status {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%3d  %-27s  %s\n", m.Version, applied, m.Description)
+		}
+	default:
+		usageError(migrateUsage)
+	}
+}
+
+func latestApplied(ctx context.Context, r repo.Repo) int {
+	status, err := r.MigrationStatus(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	latest := 0
+	for _, m := range status {
+		if m.AppliedAt != nil {
+			latest = m.Version
+		}
+	}
+	return latest
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/logger"
+)
+
+const sessionsUsage = "auth-service sessions revoke -user <user id or username>"
+
+func sessions(args []string) {
+	if len(args) == 0 || args[0] != "revoke" {
+		usageError(sessionsUsage)
+	}
+	fs := flag.NewFlagSet("sessions revoke", flag.ExitOnError)
+	userRef := fs.String("user", "", "user id or username")
+	flags := config.BindFlags(fs)
+	_ = fs.Parse(args[1:])
+	if *userRef == "" || fs.NArg() != 0 {
+		usageError(sessionsUsage)
+	}
+
+	conf := loadConfig(flags)
+	logger.Setup(conf.Server)
+	ctx := context.Background()
+	svc, r := newService(conf)
+
+	if err := svc.ForceLogout(ctx, findUser(ctx, r, *userRef).ID); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("sessions revoked")
+}
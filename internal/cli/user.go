@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/internal/service"
+	"github.com/avran02/authentication/logger"
+)
+
+const userUsage = `auth-service user create -username <name> [-email <email>] [-admin] [-password-stdin]
+       auth-service user disable <user id or username>
+       auth-service user reset-password [-password-stdin] <user id or username>`
+
+func user(args []string) {
+	if len(args) == 0 {
+		usageError(userUsage)
+	}
+	fs := flag.NewFlagSet("user "+args[0], flag.ExitOnError)
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	var username, email *string
+	var admin *bool
+	if args[0] == "create" {
+		username = fs.String("username", "", "username")
+		email = fs.String("email", "", "email")
+		admin = fs.Bool("admin", false, "grant the admin role")
+	}
+	flags := config.BindFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	conf := loadConfig(flags)
+	logger.Setup(conf.Server)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		if fs.NArg() != 0 || *username == "" {
+			usageError(userUsage)
+		}
+		var roles []string
+		if *admin {
+			roles = []string{models.RoleAdmin}
+		}
+		var emailPtr *string
+		if *email != "" {
+			emailPtr = email
+		}
+
+		svc, _ := newService(conf)
+		id, password, err := svc.CreateUser(ctx, *username, readPassword(*passwordStdin), emailPtr, roles)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("created user %s\n", id)
+		if !*passwordStdin {
+			fmt.Printf("temporary password: %s\n", password)
+		}
+	case "disable":
+		svc, r := userService(conf, fs)
+		if err := svc.SetUserStatus(ctx, findUser(ctx, r, fs.Arg(0)).ID, models.StatusDisabled); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("user disabled, its sessions are revoked")
+	case "reset-password":
+		svc, r := userService(conf, fs)
+		password, err := svc.ResetPassword(ctx, findUser(ctx, r, fs.Arg(0)).ID, readPassword(*passwordStdin))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("password reset, the sessions of the user are revoked")
+		if !*passwordStdin {
+			fmt.Printf("temporary password: %s\n", password)
+		}
+	default:
+		usageError(userUsage)
+	}
+}
+
+// userService checks that a single user was named and builds the service.
+func userService(conf *config.Config, fs *flag.FlagSet) (service.Service, repo.Repo) {
+	if fs.NArg() != 1 {
+		usageError(userUsage)
+	}
+	return newService(conf)
+}
+
+// readPassword reads the first line of stdin if fromStdin is set. Otherwise the service generates a password.
+func readPassword(fromStdin bool) string {
+	if !fromStdin {
+		return ""
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		log.Fatalf("no password on stdin: %v", err)
+	}
+	return password
+}
@@ -1,88 +1,131 @@
 package config
 
 import (
-	"log"
-	"log/slog"
-	"os"
-	"strconv"
-
-	"github.com/joho/godotenv"
+	"slices"
+	"time"
 )
 
 type Server struct {
-	Host     string
-	GRPCPort string
-	HTTPPort string
-	LogLevel string
+	Host     string `yaml:"host"`
+	GRPCPort string `yaml:"grpc_port"`
+	HTTPPort string `yaml:"http_port"`
+	LogLevel string `yaml:"log_level"`
+	// ShutdownDelay is how long readiness checks fail on shutdown before the servers stop accepting
+	// requests, so load balancers stop routing new ones here first. 0 stops right away.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// ShutdownTimeout bounds how long in-flight requests are drained on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 }
 
 type DB struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
+	// URI is a full connection string, e.g. "mongodb+srv://cluster.example/?authSource=admin".
+	// It replaces Host, Port and Hosts, the other keys override its options when set.
+	URI  string `yaml:"uri"`
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+	// Hosts lists the "host:port" seeds of a replica set, it replaces Host and Port.
+	Hosts      []string `yaml:"hosts"`
+	ReplicaSet string   `yaml:"replica_set"`
+	User       string   `yaml:"user"`
+	Password   string   `yaml:"password"`
+	// AuthSource is the database User is defined in, "admin" by default.
+	AuthSource string `yaml:"auth_source"`
+	TLS        DBTLS  `yaml:"tls"`
+
+	// The timeouts and pool sizes keep the driver defaults when zero.
+	ConnectTimeout         time.Duration `yaml:"connect_timeout"`
+	ServerSelectionTimeout time.Duration `yaml:"server_selection_timeout"`
+	MaxPoolSize            uint64        `yaml:"max_pool_size"`
+	MinPoolSize            uint64        `yaml:"min_pool_size"`
+
+	Database    string        `yaml:"database"`
+	Collections DBCollections `yaml:"collections"`
+	// AllowStandalone runs on a standalone server without transactions, for development only:
+	// state changes, their outbox events and revocations are not written atomically then,
+	// and concurrent audit events can break the audit chain.
+	AllowStandalone bool `yaml:"allow_standalone"`
 }
 
 type JWT struct {
-	Secret     string
-	AccessExp  int
-	RefreshExp int
+	Secret string `yaml:"secret"`
+	// KeysFile holds a set of signing keys to rotate, it replaces Secret.
+	KeysFile   string `yaml:"keys_file"`
+	AccessExp  int    `yaml:"access_exp"`
+	RefreshExp int    `yaml:"refresh_exp"`
 }
 
+// Config is the schema of config.yml. Every scalar key can be overridden with an env var
+// and a flag, see Load.
 type Config struct {
-	Server Server
-	DB     DB
-	JWT    JWT
-	CORS   CORSConfig
-	Cookie CookieConfig
+	Server Server       `yaml:"server"`
+	DB     DB           `yaml:"db"`
+	JWT    JWT          `yaml:"jwt"`
+	CORS   CORSConfig   `yaml:"cors"`
+	Cookie CookieConfig `yaml:"cookie"`
+	Hasher Hasher       `yaml:"password_hashing"`
+	Login  Login        `yaml:"login"`
+
+	PasswordPolicy  PasswordPolicy  `yaml:"password_policy"`
+	AccountDeletion AccountDeletion `yaml:"account_deletion"`
+	Webhooks        Webhooks        `yaml:"webhooks"`
+	Outbox          Outbox          `yaml:"outbox"`
+	Tracing         Tracing         `yaml:"tracing"`
+	Secrets         Secrets         `yaml:"secrets"`
 }
 
-func New() *Config {
-	if os.Getenv("LOAD_DOT_ENV") != "false" {
-		if err := godotenv.Load(); err != nil {
-			log.Fatal("can't load .env file")
-		}
-		slog.Info("Loaded .env file")
-	}
+const redacted = "[redacted]"
 
-	accessExpStr := os.Getenv("JWT_ACCESS_EXP")
-	refreshExpStr := os.Getenv("JWT_REFRESH_EXP")
-	accessExp, err := strconv.Atoi(accessExpStr)
-	if err != nil {
-		slog.Warn("JWT_ACCESS_EXP is not an int, using default value: 3600")
-		accessExp = 3600
+// secretFields lists the secret values. They may be references to a secret provider, see Load.
+func (c *Config) secretFields() []*string {
+	fields := []*string{&c.JWT.Secret, &c.DB.URI, &c.DB.Password, &c.Secrets.Vault.Token}
+	for i := range c.Webhooks.Endpoints {
+		fields = append(fields, &c.Webhooks.Endpoints[i].Secret)
 	}
+	return fields
+}
 
-	refreshExp, err := strconv.Atoi(refreshExpStr)
-	if err != nil {
-		slog.Warn("JWT_REFRESH_EXP is not an int, using default value: 86400")
-		refreshExp = 86400
+// Redacted returns a copy of c with the secrets replaced, safe to print and log.
+func (c Config) Redacted() Config {
+	c.Webhooks.Endpoints = slices.Clone(c.Webhooks.Endpoints)
+	for _, secret := range c.secretFields() {
+		if *secret != "" {
+			*secret = redacted
+		}
 	}
+	return c
+}
 
-	slog.Info("env config loaded")
+// presetDefaults sets the defaults of keys whose zero value is valid before the sources are read,
+// so only absent keys get them.
+func (c *Config) presetDefaults() {
+	c.Server.ShutdownDelay = 5 * time.Second //nolint:mnd
+}
+
+func (c *Config) setDefaults() {
+	setDefault(&c.Server.GRPCPort, "50051")
+	setDefault(&c.Server.HTTPPort, "8080")
+	setDefault(&c.Server.LogLevel, "info")
+	setDefault(&c.Server.ShutdownTimeout, 30*time.Second) //nolint:mnd
+	c.DB = dbWithDefaults(c.DB)
+	setDefault(&c.JWT.AccessExp, 3600)   //nolint:mnd
+	setDefault(&c.JWT.RefreshExp, 86400) //nolint:mnd
 
-	corsConfig, cookieConfig := getYmlConfig()
-	slog.Info("config.yml loaded")
+	c.Hasher = hasherWithDefaults(c.Hasher)
+	c.Login = loginWithDefaults(c.Login)
+	setDefault(&c.PasswordPolicy.MinLength, 8)                  //nolint:mnd
+	setDefault(&c.PasswordPolicy.MaxLength, 128)                //nolint:mnd
+	setDefault(&c.AccountDeletion.GracePeriod, 30*24*time.Hour) //nolint:mnd
+	setDefault(&c.AccountDeletion.PurgeInterval, time.Hour)
+	c.Webhooks = webhooksWithDefaults(c.Webhooks)
+	c.Outbox = outboxWithDefaults(c.Outbox)
+	c.Tracing = tracingWithDefaults(c.Tracing)
+	setDefault(&c.Secrets.Vault.KVVersion, 2)           //nolint:mnd
+	setDefault(&c.Secrets.Vault.Timeout, 5*time.Second) //nolint:mnd
+}
 
-	return &Config{
-		Server: Server{
-			Host:     os.Getenv("SERVER_HOST"),
-			GRPCPort: os.Getenv("SERVER_GRPC_PORT"),
-			HTTPPort: os.Getenv("SERVER_HTTP_PORT"),
-			LogLevel: os.Getenv("SERVER_LOG_LEVEL"),
-		},
-		DB: DB{
-			Host:     os.Getenv("DB_HOST"),
-			Port:     os.Getenv("DB_PORT"),
-			User:     os.Getenv("DB_USER"),
-			Password: os.Getenv("DB_PASSWORD"),
-		},
-		JWT: JWT{
-			Secret:     os.Getenv("JWT_SECRET"),
-			AccessExp:  accessExp,
-			RefreshExp: refreshExp,
-		},
-		CORS:   corsConfig,
-		Cookie: cookieConfig,
+func setDefault[T comparable](v *T, def T) {
+	var zero T
+	if *v == zero {
+		*v = def
 	}
 }
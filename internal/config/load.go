@@ -0,0 +1,260 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avran02/authentication/internal/secrets"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = "config.yml"
+
+// Flags holds the config flags of a command line, see BindFlags.
+type Flags struct {
+	file   string
+	values map[string]string
+}
+
+// BindFlags adds -config, the path of the config file, and a flag for every config key,
+// named like the key, e.g. -server.http_port, to flagSet.
+func BindFlags(flagSet *flag.FlagSet) *Flags {
+	f := &Flags{values: make(map[string]string)}
+	flagSet.StringVar(&f.file, "config", "", "path of the config file (env CONFIG_FILE, default "+defaultConfigFile+")")
+	for _, b := range bindings(&Config{}) {
+		flagSet.Func(b.key, "overrides "+b.key+" (env "+b.env+")", func(value string) error {
+			f.values[b.key] = value
+			return nil
+		})
+	}
+	return f
+}
+
+// ConfigFile returns the path of the config file: the -config flag, CONFIG_FILE or config.yml.
+func (f *Flags) ConfigFile() string {
+	if f != nil && f.file != "" {
+		return f.file
+	}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	return defaultConfigFile
+}
+
+// Load reads the config file, then applies env vars and flags, each overriding the previous source.
+// Unset keys get their defaults, then the result is validated. flags may be nil.
+func Load(flags *Flags) (*Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+	if os.Getenv("LOAD_DOT_ENV") != "false" {
+		err := godotenv.Load()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("can't load .env file: %w", err)
+		}
+		if err == nil {
+			slog.Info("Loaded .env file")
+		}
+	}
+
+	conf := &Config{}
+	conf.presetDefaults()
+	if err := readFile(conf, flags.ConfigFile()); err != nil {
+		return nil, err
+	}
+
+	binds := bindings(conf)
+	for _, b := range binds {
+		value, err := envValue(b.env)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			if err = b.set(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", b.env, err)
+			}
+		}
+	}
+	for _, b := range binds {
+		if value, ok := flags.values[b.key]; ok {
+			if err := b.set(value); err != nil {
+				return nil, fmt.Errorf("invalid -%s: %w", b.key, err)
+			}
+		}
+	}
+
+	conf.setDefaults()
+	if err := conf.resolveSecrets(); err != nil {
+		return nil, err
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// envValue returns the env var name, or the content of the file named by name_FILE.
+func envValue(name string) (string, error) {
+	value := os.Getenv(name)
+	file := os.Getenv(name + "_FILE")
+	if file == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("both %s and %s_FILE are set", name, name)
+	}
+	value, err := secrets.FileProvider{}.Get(context.Background(), file)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s_FILE: %w", name, err)
+	}
+	return value, nil
+}
+
+// resolveSecrets replaces the secret references with the secrets. The Vault token may be a file reference.
+func (c *Config) resolveSecrets() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Secrets.Vault.Timeout)
+	defer cancel()
+
+	token, err := secrets.NewResolver(secrets.VaultOptions{}).Resolve(ctx, c.Secrets.Vault.Token)
+	if err != nil {
+		return fmt.Errorf("invalid secrets.vault.token: %w", err)
+	}
+
+	resolver := secrets.NewResolver(secrets.VaultOptions{
+		Address:   c.Secrets.Vault.Address,
+		Token:     token,
+		KVVersion: c.Secrets.Vault.KVVersion,
+		Timeout:   c.Secrets.Vault.Timeout,
+	})
+	for _, field := range c.secretFields() {
+		if *field, err = resolver.Resolve(ctx, *field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFile decodes the config file into conf. The default file is optional, a file that was asked for is not.
+func readFile(conf *Config, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && path == defaultConfigFile {
+		slog.Info("no config file, using env vars and flags only", "file", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err = dec.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("can't decode %s: %w", path, err)
+	}
+	slog.Info("config file loaded", "file", path)
+	return nil
+}
+
+// binding ties a scalar config key to its env var.
+type binding struct {
+	// key is the dotted path of the key in the config file, e.g. "server.http_port".
+	key string
+	// env is the key in upper case with underscores, e.g. "SERVER_HTTP_PORT".
+	env   string
+	value reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// bindings lists the scalar keys of conf. Lists of strings are bound as comma separated values,
+// lists of objects like webhooks.endpoints can only be set in the config file.
+func bindings(conf *Config) []binding {
+	return structBindings(reflect.ValueOf(conf).Elem(), "")
+}
+
+func structBindings(v reflect.Value, prefix string) []binding {
+	var res []binding
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			res = append(res, structBindings(field, key+".")...)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.String:
+		default:
+			res = append(res, binding{
+				key:   key,
+				env:   strings.ToUpper(strings.ReplaceAll(key, ".", "_")),
+				value: field,
+			})
+		}
+	}
+	return res
+}
+
+func (b binding) set(raw string) error {
+	v := b.value
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(parsed)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type DBTLS struct {
+	Enabled bool `yaml:"enabled"`
+	// CAFile verifies the server certificate instead of the system roots.
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile are the client certificate, for X.509 authentication or mutual TLS.
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+type DBCollections struct {
+	Users              string `yaml:"users"`
+	Tokens             string `yaml:"tokens"`
+	Audit              string `yaml:"audit"`
+	Webhooks           string `yaml:"webhooks"`
+	WebhookDeadLetters string `yaml:"webhook_dead_letters"`
+	Outbox             string `yaml:"outbox"`
+	Revocations        string `yaml:"revocations"`
+	Counters           string `yaml:"counters"`
+	Migrations         string `yaml:"migrations"`
+}
+
+type CookieConfig struct {
+	HTTPOnly bool `yaml:"http_only"`
+	Secure   bool `yaml:"secure"`
+	// SameSite is "default", "lax", "strict" or "none".
+	SameSite    string `yaml:"same_site"`
+	Domain      string `yaml:"domain"`
+	Partitioned bool   `yaml:"partitioned"`
+}
+
+// SameSiteMode returns the SameSite attribute of the cookies.
+func (c CookieConfig) SameSiteMode() http.SameSite {
+	switch c.SameSite {
+	case "none":
+		return http.SameSiteNoneMode
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+}
+
+type Login struct {
+	// Identifiers users may log in with: "username" and/or "email".
+	Identifiers []string `yaml:"identifiers"`
+	// MaxAttempts is how many logins a client IP may attempt per AttemptsPeriod, 0 disables the limit.
+	MaxAttempts    int           `yaml:"max_attempts"`
+	AttemptsPeriod time.Duration `yaml:"attempts_period"`
+}
+
+func (l Login) Allows(identifier string) bool {
+	return slices.Contains(l.Identifiers, identifier)
+}
+
+// PasswordPolicy limits the length of new passwords in characters.
+type PasswordPolicy struct {
+	MinLength int `yaml:"min_length"`
+	MaxLength int `yaml:"max_length"`
+}
+
+type AccountDeletion struct {
+	// GracePeriod is how long a deleted account is kept before it is purged.
+	GracePeriod   time.Duration `yaml:"grace_period"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type Webhooks struct {
+	// Endpoints receive events in addition to the ones registered with the admin API.
+	Endpoints []WebhookEndpoint `yaml:"endpoints"`
+	// MaxAttempts is the number of outbox publications after which a failing delivery is dead lettered.
+	MaxAttempts int `yaml:"max_attempts"`
+	// Timeout bounds each delivery request and loading the endpoints registered with the admin API.
+	Timeout time.Duration `yaml:"timeout"`
+	// Workers is the number of endpoints an event is sent to at once.
+	Workers int `yaml:"workers"`
+}
+
+type WebhookEndpoint struct {
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"`
+}
+
+type Outbox struct {
+	// Sink publishes the events: "webhook", "nats", "kafka" or "file".
+	Sink         string        `yaml:"sink"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	// Lease is how long an event stays locked by the instance publishing it.
+	Lease time.Duration `yaml:"lease"`
+	// MaxBackoff caps the delay between attempts to publish an event.
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	NATS       NATSSink      `yaml:"nats"`
+	Kafka      KafkaSink     `yaml:"kafka"`
+	File       FileSink      `yaml:"file"`
+}
+
+// NATSSink publishes to JetStream subjects "<subject_prefix>.<event type>".
+type NATSSink struct {
+	URL           string `yaml:"url"`
+	SubjectPrefix string `yaml:"subject_prefix"`
+}
+
+type KafkaSink struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+}
+
+// FileSink appends JSON lines to Path, "-" is stdout.
+type FileSink struct {
+	Path string `yaml:"path"`
+}
+
+// Tracing exports OpenTelemetry spans.
+type Tracing struct {
+	// Exporter is "none", "otlp" or "stdout".
+	Exporter    string `yaml:"exporter"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio is the share of new traces that are sampled, zero means all of them.
+	// Traces started upstream follow the decision of the caller.
+	SampleRatio float64    `yaml:"sample_ratio"`
+	OTLP        OTLPTraces `yaml:"otlp"`
+}
+
+// OTLPTraces exports spans over OTLP/gRPC.
+type OTLPTraces struct {
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+}
+
+// Secrets configures the providers of secret references.
+type Secrets struct {
+	Vault VaultSecrets `yaml:"vault"`
+}
+
+// VaultSecrets reads "vault:<mount>/<path>#<key>" references from a KV secrets engine.
+type VaultSecrets struct {
+	Address   string        `yaml:"address"`
+	Token     string        `yaml:"token"`
+	KVVersion int           `yaml:"kv_version"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+type Hasher struct {
+	Algorithm string   `yaml:"algorithm"`
+	Argon2id  Argon2id `yaml:"argon2id"`
+	Scrypt    Scrypt   `yaml:"scrypt"`
+	Bcrypt    Bcrypt   `yaml:"bcrypt"`
+}
+
+type Argon2id struct {
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"salt_length"`
+	KeyLength   uint32 `yaml:"key_length"`
+}
+
+type Scrypt struct {
+	CostLog2    uint8  `yaml:"cost_log2"`
+	BlockSize   int    `yaml:"block_size"`
+	Parallelism int    `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"salt_length"`
+	KeyLength   uint32 `yaml:"key_length"`
+}
+
+type Bcrypt struct {
+	Cost int `yaml:"cost"`
+}
+
+func dbWithDefaults(d DB) DB {
+	if d.URI == "" && len(d.Hosts) == 0 {
+		setDefault(&d.Host, "localhost")
+		setDefault(&d.Port, "27017")
+	}
+	setDefault(&d.Database, "auth")
+	setDefault(&d.Collections.Users, "users")
+	setDefault(&d.Collections.Tokens, "tokens")
+	setDefault(&d.Collections.Audit, "audit")
+	setDefault(&d.Collections.Webhooks, "webhooks")
+	setDefault(&d.Collections.WebhookDeadLetters, "webhook_dead_letters")
+	setDefault(&d.Collections.Outbox, "outbox")
+	setDefault(&d.Collections.Revocations, "revocations")
+	setDefault(&d.Collections.Counters, "counters")
+	setDefault(&d.Collections.Migrations, "migrations")
+	return d
+}
+
+func tracingWithDefaults(t Tracing) Tracing {
+	setDefault(&t.Exporter, "none")
+	setDefault(&t.ServiceName, "auth-service")
+	setDefault(&t.SampleRatio, 1)
+	setDefault(&t.OTLP.Endpoint, "localhost:4317")
+	return t
+}
+
+func outboxWithDefaults(o Outbox) Outbox {
+	setDefault(&o.Sink, "webhook")
+	setDefault(&o.PollInterval, time.Second)
+	setDefault(&o.BatchSize, 100)            //nolint:mnd
+	setDefault(&o.Lease, 30*time.Second)     //nolint:mnd
+	setDefault(&o.MaxBackoff, 5*time.Minute) //nolint:mnd
+	setDefault(&o.NATS.URL, "nats://localhost:4222")
+	setDefault(&o.NATS.SubjectPrefix, "auth.events")
+	setDefault(&o.Kafka.Topic, "auth.events")
+	setDefault(&o.File.Path, "-")
+	return o
+}
+
+func webhooksWithDefaults(w Webhooks) Webhooks {
+	setDefault(&w.MaxAttempts, 8)          //nolint:mnd
+	setDefault(&w.Timeout, 10*time.Second) //nolint:mnd
+	setDefault(&w.Workers, 4)              //nolint:mnd
+	return w
+}
+
+func loginWithDefaults(l Login) Login {
+	if len(l.Identifiers) == 0 {
+		l.Identifiers = []string{"username", "email"}
+	}
+	setDefault(&l.AttemptsPeriod, time.Minute)
+	return l
+}
+
+// hasherWithDefaults fills unset parameters with the RFC 9106 and OWASP recommendations.
+func hasherWithDefaults(h Hasher) Hasher {
+	setDefault(&h.Algorithm, "argon2id")
+
+	setDefault(&h.Argon2id.Memory, 64*1024)
+	setDefault(&h.Argon2id.Iterations, 3)
+	setDefault(&h.Argon2id.Parallelism, 4)
+	setDefault(&h.Argon2id.SaltLength, 16)
+	setDefault(&h.Argon2id.KeyLength, 32)
+
+	setDefault(&h.Scrypt.CostLog2, 17)
+	setDefault(&h.Scrypt.BlockSize, 8)
+	setDefault(&h.Scrypt.Parallelism, 1)
+	setDefault(&h.Scrypt.SaltLength, 16)
+	setDefault(&h.Scrypt.KeyLength, 32)
+
+	setDefault(&h.Bcrypt.Cost, bcrypt.DefaultCost)
+
+	return h
+}
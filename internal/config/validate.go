@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/avran02/authentication/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinJWTSecretLength is the size of the HS512 hash output, the minimal key size by RFC 7518.
+const MinJWTSecretLength = 64
+
+// Limits of the password hashing parameters. They match the limits internal/pkg/hasher enforces on
+// stored hashes, so the hashes the service writes can be verified.
+const (
+	maxArgon2Memory      = 1 << 21 // KiB
+	maxArgon2Iterations  = 64
+	maxScryptCostLog2    = 24
+	maxScryptMemory      = 1 << 31 // bytes, scrypt needs 128 * block_size * 2^cost_log2
+	maxScryptParallelism = 64
+	maxBcryptCost        = 16
+	minSaltLength        = 8
+	minKeyLength         = 16
+	maxKeyLength         = 128
+)
+
+// maxPasswordLength is the longest password the HTTP DTOs accept.
+const maxPasswordLength = 128
+
+// Validate reports all invalid keys at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.HTTPPort), "server.http_port must be a port number, got %q", c.Server.HTTPPort)
+	check(validPort(c.Server.GRPCPort), "server.grpc_port must be a port number, got %q", c.Server.GRPCPort)
+	check(c.Server.HTTPPort != c.Server.GRPCPort, "server.http_port and server.grpc_port must differ")
+	check(oneOf(strings.ToLower(c.Server.LogLevel), "debug", "info", "warn", "error"),
+		"unknown server.log_level: %q", c.Server.LogLevel)
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	if c.DB.URI != "" {
+		check(strings.HasPrefix(c.DB.URI, "mongodb://") || strings.HasPrefix(c.DB.URI, "mongodb+srv://"),
+			"db.uri must start with mongodb:// or mongodb+srv://")
+	} else if len(c.DB.Hosts) == 0 {
+		check(c.DB.Host != "", "db.host is required")
+		check(validPort(c.DB.Port), "db.port must be a port number, got %q", c.DB.Port)
+	}
+	for _, host := range c.DB.Hosts {
+		_, port, err := net.SplitHostPort(host)
+		check(err == nil && validPort(port), "db.hosts must be host:port pairs, got %q", host)
+	}
+	check(c.DB.User == "" || c.DB.Password != "", "db.password is required with db.user")
+	check((c.DB.TLS.CertFile == "") == (c.DB.TLS.KeyFile == ""), "db.tls.cert_file and db.tls.key_file must be set together")
+	check(c.DB.TLS.Enabled || c.DB.TLS.CAFile == "" && c.DB.TLS.CertFile == "" && !c.DB.TLS.InsecureSkipVerify,
+		"db.tls.enabled must be set to use the other db.tls keys")
+	check(c.DB.MinPoolSize <= c.DB.MaxPoolSize || c.DB.MaxPoolSize == 0,
+		"db.min_pool_size must not exceed db.max_pool_size")
+	check(c.DB.Database != "", "db.database is required")
+
+	check(c.JWT.Secret != "" || c.JWT.KeysFile != "", "jwt.secret or jwt.keys_file is required")
+	check(c.JWT.Secret == "" || len(c.JWT.Secret) >= MinJWTSecretLength,
+		"jwt.secret is too weak: it must be at least %d bytes, got %d", MinJWTSecretLength, len(c.JWT.Secret))
+	check(c.JWT.AccessExp > 0, "jwt.access_exp must be positive")
+	check(c.JWT.RefreshExp > 0, "jwt.refresh_exp must be positive")
+
+	check(oneOf(c.Cookie.SameSite, "", "default", "lax", "strict", "none"),
+		"unknown cookie.same_site: %q, use default, lax, strict or none", c.Cookie.SameSite)
+	check(c.Cookie.SameSite != "none" || c.Cookie.Secure, "cookie.same_site none requires cookie.secure")
+	check(!c.Cookie.Partitioned || c.Cookie.Secure, "cookie.partitioned requires cookie.secure")
+
+	check(oneOf(c.Hasher.Algorithm, "argon2id", "scrypt", "bcrypt"),
+		"unknown password_hashing.algorithm: %q", c.Hasher.Algorithm)
+	argon2id := c.Hasher.Argon2id
+	check(argon2id.Memory >= 8*uint32(argon2id.Parallelism) && argon2id.Memory <= maxArgon2Memory,
+		"password_hashing.argon2id.memory must be between 8 * parallelism and %d KiB, got %d", maxArgon2Memory, argon2id.Memory)
+	check(argon2id.Iterations <= maxArgon2Iterations,
+		"password_hashing.argon2id.iterations must be at most %d, got %d", maxArgon2Iterations, argon2id.Iterations)
+	check(argon2id.SaltLength >= minSaltLength,
+		"password_hashing.argon2id.salt_length must be at least %d, got %d", minSaltLength, argon2id.SaltLength)
+	check(argon2id.KeyLength >= minKeyLength && argon2id.KeyLength <= maxKeyLength,
+		"password_hashing.argon2id.key_length must be between %d and %d, got %d", minKeyLength, maxKeyLength, argon2id.KeyLength)
+	scrypt := c.Hasher.Scrypt
+	check(scrypt.CostLog2 <= maxScryptCostLog2,
+		"password_hashing.scrypt.cost_log2 must be at most %d, got %d", maxScryptCostLog2, scrypt.CostLog2)
+	check(scrypt.BlockSize >= 1 && scrypt.BlockSize <= maxScryptMemory/128>>scrypt.CostLog2,
+		"password_hashing.scrypt.block_size must be positive and 128 * block_size * 2^cost_log2 at most %d bytes", maxScryptMemory)
+	check(scrypt.Parallelism >= 1 && scrypt.Parallelism <= maxScryptParallelism,
+		"password_hashing.scrypt.parallelism must be between 1 and %d, got %d", maxScryptParallelism, scrypt.Parallelism)
+	check(scrypt.SaltLength >= minSaltLength,
+		"password_hashing.scrypt.salt_length must be at least %d, got %d", minSaltLength, scrypt.SaltLength)
+	check(scrypt.KeyLength >= minKeyLength && scrypt.KeyLength <= maxKeyLength,
+		"password_hashing.scrypt.key_length must be between %d and %d, got %d", minKeyLength, maxKeyLength, scrypt.KeyLength)
+	check(c.Hasher.Bcrypt.Cost >= bcrypt.MinCost && c.Hasher.Bcrypt.Cost <= maxBcryptCost,
+		"password_hashing.bcrypt.cost must be between %d and %d, got %d", bcrypt.MinCost, maxBcryptCost, c.Hasher.Bcrypt.Cost)
+
+	for _, identifier := range c.Login.Identifiers {
+		check(oneOf(identifier, "username", "email"), "unknown login identifier: %q", identifier)
+	}
+	check(c.Login.MaxAttempts >= 0, "login.max_attempts must not be negative")
+	check(c.Login.AttemptsPeriod > 0, "login.attempts_period must be positive")
+
+	check(c.PasswordPolicy.MinLength >= 1 && c.PasswordPolicy.MinLength <= c.PasswordPolicy.MaxLength,
+		"password_policy.min_length must be between 1 and password_policy.max_length, got %d", c.PasswordPolicy.MinLength)
+	check(c.PasswordPolicy.MaxLength <= maxPasswordLength,
+		"password_policy.max_length must be at most %d, got %d", maxPasswordLength, c.PasswordPolicy.MaxLength)
+
+	check(c.AccountDeletion.GracePeriod > 0, "account_deletion.grace_period must be positive")
+	check(c.AccountDeletion.PurgeInterval > 0, "account_deletion.purge_interval must be positive")
+
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(c.Webhooks.Workers > 0, "webhooks.workers must be positive")
+
+	for _, endpoint := range c.Webhooks.Endpoints {
+		check(endpoint.URL != "" && endpoint.Secret != "", "webhook endpoints need an url and a secret")
+		for _, event := range endpoint.Events {
+			check(models.EventType(event).IsValid(), "unknown webhook event: %q", event)
+		}
+	}
+
+	check(oneOf(c.Outbox.Sink, "webhook", "nats", "kafka", "file"), "unknown outbox.sink: %q", c.Outbox.Sink)
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval must be positive")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size must be positive")
+	check(c.Outbox.Lease > 0, "outbox.lease must be positive")
+	check(c.Outbox.MaxBackoff > 0, "outbox.max_backoff must be positive")
+	check(c.Outbox.Sink != "kafka" || len(c.Outbox.Kafka.Brokers) > 0,
+		"outbox.kafka.brokers must be set for the kafka sink")
+
+	check(oneOf(c.Tracing.Exporter, "none", "otlp", "stdout"), "unknown tracing.exporter: %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+
+	return errors.Join(errs...)
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func oneOf(value string, allowed ...string) bool {
+	return slices.Contains(allowed, value)
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
+	"github.com/avran02/authentication/internal/service"
+	pb "github.com/avran02/authentication/pb"
+)
+
+// AdminGrpcController backs pb.AdminServiceServer. Methods are prefixed to not clash with HTTP handlers.
+type AdminGrpcController interface {
+	AdminListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error)
+	AdminGetUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.User, error)
+	AdminDisableUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error)
+	AdminEnableUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error)
+	AdminForceLogout(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error)
+	AdminResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error)
+	AdminSetUserStatus(ctx context.Context, req *pb.SetUserStatusRequest) (*pb.AdminResponse, error)
+	AdminSetUserRoles(ctx context.Context, req *pb.SetUserRolesRequest) (*pb.AdminResponse, error)
+	AdminDeleteUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error)
+}
+
+func (c *grpcController) AdminListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	ctx, err := c.authorizeAdmin(ctx, req.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	users, total, err := c.service.ListUsers(ctx, models.UserFilter{
+		Query:  req.Query,
+		Role:   req.Role,
+		Status: models.UserStatus(req.Status),
+	}, int(req.Page), int(req.Limit))
+	if err != nil {
+		return nil, grpcError(err, "failed to list users")
+	}
+
+	resp := &pb.ListUsersResponse{
+		Users: make([]*pb.User, 0, len(users)),
+		Total: total,
+	}
+	for i := range users {
+		user, err := pbUser(&users[i])
+		if err != nil {
+			return nil, err
+		}
+		resp.Users = append(resp.Users, user)
+	}
+	return resp, nil
+}
+
+func (c *grpcController) AdminGetUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.User, error) {
+	ctx, err := c.authorizeAdmin(ctx, req.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := c.service.GetUser(ctx, req.UserId)
+	if err != nil {
+		return nil, grpcError(err, "failed to get user")
+	}
+	return pbUser(user)
+}
+
+func (c *grpcController) AdminDisableUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	return c.adminAction(ctx, req.AccessToken, func(ctx context.Context) error {
+		return c.service.SetUserStatus(ctx, req.UserId, models.StatusDisabled)
+	})
+}
+
+func (c *grpcController) AdminEnableUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	return c.adminAction(ctx, req.AccessToken, func(ctx context.Context) error {
+		return c.service.SetUserStatus(ctx, req.UserId, models.StatusActive)
+	})
+}
+
+func (c *grpcController) AdminForceLogout(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	return c.adminAction(ctx, req.AccessToken, func(ctx context.Context) error {
+		return c.service.ForceLogout(ctx, req.UserId)
+	})
+}
+
+func (c *grpcController) AdminResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	ctx, err := c.authorizeAdmin(ctx, req.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := c.service.ResetPassword(ctx, req.UserId, req.Password)
+	if err != nil {
+		return nil, grpcError(err, "failed to reset password")
+	}
+	return &pb.ResetPasswordResponse{Password: password}, nil
+}
+
+func (c *grpcController) AdminSetUserStatus(ctx context.Context, req *pb.SetUserStatusRequest) (*pb.AdminResponse, error) {
+	return c.adminAction(ctx, req.AccessToken, func(ctx context.Context) error {
+		return c.service.SetUserStatus(ctx, req.UserId, models.UserStatus(req.Status))
+	})
+}
+
+func (c *grpcController) AdminSetUserRoles(ctx context.Context, req *pb.SetUserRolesRequest) (*pb.AdminResponse, error) {
+	return c.adminAction(ctx, req.AccessToken, func(ctx context.Context) error {
+		return c.service.SetUserRoles(ctx, req.UserId, req.Roles)
+	})
+}
+
+func (c *grpcController) AdminDeleteUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	return c.adminAction(ctx, req.AccessToken, func(ctx context.Context) error {
+		return c.service.DeleteUser(ctx, req.UserId)
+	})
+}
+
+func (c *grpcController) adminAction(
+	ctx context.Context,
+	accessToken string,
+	action func(ctx context.Context) error,
+) (*pb.AdminResponse, error) {
+	ctx, err := c.authorizeAdmin(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := action(ctx); err != nil {
+		return nil, grpcError(err, "admin action failed")
+	}
+	return &pb.AdminResponse{Ok: true}, nil
+}
+
+// authorizeAdmin returns ctx with the admin recorded as the actor of the request.
+func (c *grpcController) authorizeAdmin(ctx context.Context, accessToken string) (context.Context, error) {
+	claims, err := c.service.Authenticate(ctx, accessToken)
+	if err != nil {
+		return nil, grpcError(err, "failed to validate token")
+	}
+	if !isAdmin(claims) {
+		return nil, grpcError(service.ErrForbidden, "failed to authorize admin")
+	}
+	return requestinfo.WithActor(ctx, claims.Subject), nil
+}
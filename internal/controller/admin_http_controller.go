@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avran02/authentication/internal/dto"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHTTPController interface {
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	GetUserByID(w http.ResponseWriter, r *http.Request)
+	DisableUser(w http.ResponseWriter, r *http.Request)
+	EnableUser(w http.ResponseWriter, r *http.Request)
+	ForceLogout(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	SetUserStatus(w http.ResponseWriter, r *http.Request)
+	SetUserRoles(w http.ResponseWriter, r *http.Request)
+	DeleteUser(w http.ResponseWriter, r *http.Request)
+	ListAuditEvents(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *httpController) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter := models.UserFilter{
+		Query:  query.Get("q"),
+		Role:   query.Get("role"),
+		Status: models.UserStatus(query.Get("status")),
+	}
+
+	users, total, err := c.service.ListUsers(r.Context(), filter, page, limit)
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	resp := dto.ListUsersResponse{
+		Users: make([]dto.UserResponse, 0, len(users)),
+		Total: total,
+	}
+	for i := range users {
+		resp.Users = append(resp.Users, userResponse(&users[i]))
+	}
+
+	writeJSON(w, resp)
+}
+
+func (c *httpController) GetUserByID(w http.ResponseWriter, r *http.Request) {
+	user, err := c.service.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, userResponse(user))
+}
+
+func (c *httpController) DisableUser(w http.ResponseWriter, r *http.Request) {
+	if err := c.service.SetUserStatus(r.Context(), chi.URLParam(r, "id"), models.StatusDisabled); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) EnableUser(w http.ResponseWriter, r *http.Request) {
+	if err := c.service.SetUserStatus(r.Context(), chi.URLParam(r, "id"), models.StatusActive); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	if err := c.service.ForceLogout(r.Context(), chi.URLParam(r, "id")); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	password, err := c.service.ResetPassword(r.Context(), chi.URLParam(r, "id"), req.Password)
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.ResetPasswordResponse{Password: password})
+}
+
+func (c *httpController) SetUserStatus(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetStatusRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	if err := c.service.SetUserStatus(r.Context(), chi.URLParam(r, "id"), models.UserStatus(req.Status)); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetRolesRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	if err := c.service.SetUserRoles(r.Context(), chi.URLParam(r, "id"), req.Roles); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := c.service.DeleteUser(r.Context(), chi.URLParam(r, "id")); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter := models.AuditFilter{
+		UserID:  query.Get("userId"),
+		Type:    models.AuditEventType(query.Get("type")),
+		Outcome: models.AuditOutcome(query.Get("outcome")),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			apiError(w, r, fmt.Errorf("%w: invalid from: %w", ErrMalformedRequest, err))
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			apiError(w, r, fmt.Errorf("%w: invalid to: %w", ErrMalformedRequest, err))
+			return
+		}
+	}
+
+	events, total, err := c.service.ListAuditEvents(r.Context(), filter, page, limit)
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.ListAuditEventsResponse{
+		Events: auditEventResponses(events),
+		Total:  total,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		// the status line is already sent, so the client can only see a truncated body
+		slog.Error("failed to write response", "error", err.Error())
+	}
+}
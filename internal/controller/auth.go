@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
+	"github.com/avran02/authentication/internal/service"
+)
+
+type contextKey string
+
+const claimsContextKey contextKey = "claims"
+
+// Authenticate validates the bearer access token and stores its claims in the request context.
+func (c *httpController) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			apiError(w, r, ErrMissingAccessToken)
+			return
+		}
+
+		claims, err := c.service.Authenticate(r.Context(), token)
+		if err != nil {
+			apiError(w, r, err)
+			return
+		}
+
+		ctx := requestinfo.WithActor(r.Context(), claims.Subject)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, claimsContextKey, claims)))
+	})
+}
+
+// RequireAdmin must be used after Authenticate.
+func (c *httpController) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(claimsFromContext(r.Context())) {
+			apiError(w, r, service.ErrForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isAdmin(claims models.AccessTokenClaims) bool {
+	return slices.Contains(claims.Roles, models.RoleAdmin)
+}
+
+func claimsFromContext(ctx context.Context) models.AccessTokenClaims {
+	claims, _ := ctx.Value(claimsContextKey).(models.AccessTokenClaims)
+	return claims
+}
+
+func userIDFromContext(ctx context.Context) string {
+	return claimsFromContext(ctx).Subject
+}
@@ -1,14 +1,135 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+
+	"github.com/avran02/authentication/internal/dto"
+	"github.com/avran02/authentication/internal/pkg/jwt"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/internal/service"
+	pb "github.com/avran02/authentication/pb"
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrMissingAccessToken = errors.New("missing bearer access token")
+	ErrMalformedRequest   = errors.New("malformed request")
+	ErrRequestTooLarge    = errors.New("request body is too large")
+	ErrValidationFailed   = errors.New("request validation failed")
 )
 
-func apiError(w http.ResponseWriter, status int, err error) {
-	slog.Error("failed to unmarshal JSON", "error", err.Error())
+// problemContentType is the media type of RFC 7807 error responses.
+const problemContentType = "application/problem+json"
+
+type errorMapping struct {
+	err        error
+	httpStatus int
+	grpcCode   codes.Code
+	reason     pb.ErrorReason
+}
+
+// internalError is used for errors missing from errorMappings.
+var internalError = errorMapping{nil, http.StatusInternalServerError, codes.Internal, pb.ErrorReason_INTERNAL}
+
+// errorMappings maps errors to the HTTP status, gRPC code and reason clients see. The first match wins.
+var errorMappings = []errorMapping{
+	{jwt.ErrEmptyToken, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_MISSING},
+	{ErrMissingAccessToken, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_MISSING},
+	{jwt.ErrExpiredToken, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_EXPIRED},
+	{jwt.ErrInvalidToken, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_INVALID},
+	{service.ErrTokenDoesntExist, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_REVOKED},
+	{service.ErrWrongTokensPair, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_REVOKED},
+	{repo.ErrTokenNotFound, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_TOKEN_REVOKED},
+	{service.ErrWrongCredentials, http.StatusUnauthorized, codes.Unauthenticated, pb.ErrorReason_WRONG_CREDENTIALS},
+	{repo.ErrUserNotFound, http.StatusNotFound, codes.NotFound, pb.ErrorReason_USER_NOT_FOUND},
+	{service.ErrUserNotFound, http.StatusNotFound, codes.NotFound, pb.ErrorReason_USER_NOT_FOUND},
+	{repo.ErrWebhookEndpointNotFound, http.StatusNotFound, codes.NotFound, pb.ErrorReason_WEBHOOK_NOT_FOUND},
+	{service.ErrUserAlreadyExists, http.StatusConflict, codes.AlreadyExists, pb.ErrorReason_USER_ALREADY_EXISTS},
+	{repo.ErrDuplicateUser, http.StatusConflict, codes.AlreadyExists, pb.ErrorReason_USER_ALREADY_EXISTS},
+	{service.ErrAccountDeleted, http.StatusForbidden, codes.PermissionDenied, pb.ErrorReason_ACCOUNT_DELETED},
+	{service.ErrAccountDisabled, http.StatusForbidden, codes.PermissionDenied, pb.ErrorReason_ACCOUNT_DISABLED},
+	{service.ErrAccountLocked, http.StatusForbidden, codes.PermissionDenied, pb.ErrorReason_ACCOUNT_LOCKED},
+	{service.ErrAccountPending, http.StatusForbidden, codes.PermissionDenied, pb.ErrorReason_ACCOUNT_PENDING_VERIFICATION},
+	{service.ErrForbidden, http.StatusForbidden, codes.PermissionDenied, pb.ErrorReason_PERMISSION_DENIED},
+	{service.ErrTooManyAttempts, http.StatusTooManyRequests, codes.ResourceExhausted, pb.ErrorReason_RATE_LIMITED},
+	{ErrMalformedRequest, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_MALFORMED_REQUEST},
+	{ErrRequestTooLarge, http.StatusRequestEntityTooLarge, codes.InvalidArgument, pb.ErrorReason_REQUEST_TOO_LARGE},
+	{ErrValidationFailed, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrEmptyUsername, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidUsername, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidPassword, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidEmail, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidAvatarURL, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidLocale, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidStatus, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrInvalidWebhookURL, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrUnknownEvent, http.StatusBadRequest, codes.InvalidArgument, pb.ErrorReason_INVALID_ARGUMENT},
+	{service.ErrCursorExpired, http.StatusBadRequest, codes.OutOfRange, pb.ErrorReason_CURSOR_EXPIRED},
+}
+
+func mapError(err error) errorMapping {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m
+		}
+	}
+	return internalError
+}
+
+// errorDetail is the message clients see for err: the message of the matched sentinel error,
+// so wrapped context like database errors never reaches them. ErrMalformedRequest,
+// ErrRequestTooLarge and service.ErrInvalidPassword only wrap details of the request itself
+// or the password policy and are shown in full.
+func errorDetail(m errorMapping, err error) string {
+	switch m.err {
+	case nil:
+		return "internal error"
+	case ErrMalformedRequest, ErrRequestTooLarge, service.ErrInvalidPassword:
+		return err.Error()
+	default:
+		return m.err.Error()
+	}
+}
+
+// apiError writes err as an RFC 7807 problem with the errorDetail of err.
+// Validation failures list the invalid fields.
+func apiError(w http.ResponseWriter, r *http.Request, err error) {
+	m := mapError(err)
+	requestID := middleware.GetReqID(r.Context())
+	if m.err == nil {
+		slog.Error("request failed", "path", r.URL.Path, "requestId", requestID, "error", err.Error())
+		writeProblem(w, r, m.httpStatus, m.reason, errorDetail(m, err), nil)
+		return
+	}
+
+	slog.Info("request rejected", "path", r.URL.Path, "requestId", requestID, "reason", m.reason.String(), "error", err.Error())
+	detail := errorDetail(m, err)
+	var fields []dto.FieldError
+	var validationErr *validationError
+	if errors.As(err, &validationErr) {
+		fields = validationErr.fields
+	}
+	writeProblem(w, r, m.httpStatus, m.reason, detail, fields)
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, reason pb.ErrorReason, detail string, fields []dto.FieldError) {
+	w.Header().Set("Content-Type", problemContentType)
 	w.WriteHeader(status)
-	if _, err := w.Write([]byte(err.Error())); err != nil {
-		slog.Error("failed to write response", "error", err.Error)
+	err := json.NewEncoder(w).Encode(dto.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      reason.String(),
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    fields,
+	})
+	if err != nil {
+		slog.Error("failed to write response", "error", err.Error())
 	}
 }
@@ -2,15 +2,30 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"slices"
 
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/jwt"
+	"github.com/avran02/authentication/internal/repo"
 	"github.com/avran02/authentication/internal/service"
 	pb "github.com/avran02/authentication/pb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// GrpcController methods that clash with HTTPController handlers are prefixed with Grpc.
 type GrpcController interface {
+	GrpcRegister(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error)
+	GrpcLogin(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error)
+	GrpcRefreshTokens(ctx context.Context, req *pb.RefreshTokensRequest) (*pb.RefreshTokensResponse, error)
+	GrpcLogout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error)
 	ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error)
+	GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error)
+	UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error)
+	WatchRevocations(req *pb.WatchRevocationsRequest, stream pb.AuthService_WatchRevocationsServer) error
+	AdminGrpcController
 }
 
 // implements pb.AuthServiceServer.
@@ -18,17 +33,158 @@ type grpcController struct {
 	service service.Service
 }
 
+func (c *grpcController) GrpcRegister(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	id, accessToken, refreshToken, expTime, err := c.service.Register(ctx, req.Username, req.Password, req.Email)
+	if err != nil {
+		return nil, grpcError(err, "failed to register")
+	}
+	return &pb.RegisterResponse{
+		Id:                    id,
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(expTime),
+	}, nil
+}
+
+func (c *grpcController) GrpcLogin(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	id, accessToken, refreshToken, expTime, err := c.service.Login(ctx, req.Login, req.Password)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		// don't tell apart unknown users and wrong passwords
+		err = service.ErrWrongCredentials
+	}
+	if err != nil {
+		return nil, grpcError(err, "failed to login")
+	}
+	return &pb.LoginResponse{
+		Id:                    id,
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(expTime),
+	}, nil
+}
+
+func (c *grpcController) GrpcRefreshTokens(ctx context.Context, req *pb.RefreshTokensRequest) (*pb.RefreshTokensResponse, error) {
+	accessToken, refreshToken, expTime, err := c.service.RefreshTokens(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, grpcError(err, "failed to refresh tokens")
+	}
+	return &pb.RefreshTokensResponse{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(expTime),
+	}, nil
+}
+
+func (c *grpcController) GrpcLogout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	ok, err := c.service.Logout(ctx, req.AccessToken)
+	if err != nil {
+		return nil, grpcError(err, "failed to logout")
+	}
+	return &pb.LogoutResponse{Ok: ok}, nil
+}
+
 func (c *grpcController) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
 	id, err := c.service.ValidateToken(ctx, req.AccessToken)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, fmt.Errorf("failed to validate token: %w", err)
+		return nil, grpcError(err, "failed to validate token")
 	}
 	return &pb.ValidateTokenResponse{
 		Id: id,
 	}, nil
 }
 
+func (c *grpcController) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	id, err := c.service.ValidateToken(ctx, req.AccessToken)
+	if err != nil {
+		return nil, grpcError(err, "failed to validate token")
+	}
+
+	user, err := c.service.GetUser(ctx, id)
+	if err != nil {
+		return nil, grpcError(err, "failed to get user")
+	}
+	return pbUser(user)
+}
+
+func (c *grpcController) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	id, err := c.service.ValidateToken(ctx, req.AccessToken)
+	if err != nil {
+		return nil, grpcError(err, "failed to validate token")
+	}
+
+	update := models.UserUpdate{
+		Username:    req.Username,
+		Email:       req.Email,
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		Locale:      req.Locale,
+	}
+	if req.Metadata != nil {
+		update.Metadata = req.Metadata.AsMap()
+	}
+
+	user, err := c.service.UpdateUser(ctx, id, update)
+	if err != nil {
+		return nil, grpcError(err, "failed to update user")
+	}
+	return pbUser(user)
+}
+
+func (c *grpcController) WatchRevocations(req *pb.WatchRevocationsRequest, stream pb.AuthService_WatchRevocationsServer) error {
+	claims, err := c.service.Authenticate(stream.Context(), req.AccessToken)
+	if err != nil {
+		return grpcError(err, "failed to validate token")
+	}
+	if !isAdmin(claims) && !slices.Contains(claims.Roles, models.RoleRevocationsReader) {
+		return grpcError(service.ErrForbidden, "failed to authorize revocations reader")
+	}
+
+	// the stream ends when the access token expires, clients reconnect with a fresh one
+	ctx := stream.Context()
+	if claims.ExpiresAt != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, claims.ExpiresAt.Time)
+		defer cancel()
+	}
+	err = c.service.WatchRevocations(ctx, req.Cursor, func(revocation models.Revocation) error {
+		return stream.Send(&pb.Revocation{
+			Cursor:    revocation.Seq,
+			UserId:    revocation.UserID,
+			Reason:    revocation.Reason,
+			RevokedAt: timestamppb.New(revocation.RevokedAt),
+		})
+	})
+	if stream.Context().Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return grpcError(jwt.ErrExpiredToken, "revocations stream closed")
+	}
+	if err != nil {
+		return grpcError(err, "failed to watch revocations")
+	}
+	return nil
+}
+
+func pbUser(user *models.User) (*pb.User, error) {
+	metadata, err := structpb.NewStruct(user.Metadata)
+	if err != nil {
+		return nil, grpcError(fmt.Errorf("user %s metadata: %w", user.ID, err), "failed to convert user")
+	}
+
+	return &pb.User{
+		Id:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		DisplayName:   user.DisplayName,
+		AvatarURL:     user.AvatarURL,
+		Locale:        user.Locale,
+		Metadata:      metadata,
+		Roles:         user.Roles,
+		Status:        string(userStatus(user)),
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+		UpdatedAt:     timestamppb.New(user.UpdatedAt),
+	}, nil
+}
+
 func newGrpcController(service service.Service) GrpcController {
 	return &grpcController{
 		service: service,
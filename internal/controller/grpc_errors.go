@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorInfoDomain is the domain of the errdetails.ErrorInfo attached to status errors.
+const errorInfoDomain = "auth"
+
+// grpcError logs err and converts it to a status error with an errdetails.ErrorInfo
+// carrying a stable pb.ErrorReason, so clients don't have to parse messages. Like apiError,
+// the message only has the errorDetail of err, the full error is only logged.
+func grpcError(err error, msg string) error {
+	m := mapError(err)
+	code, reason := m.grpcCode, m.reason
+
+	if code == codes.Internal {
+		slog.Error(msg, "error", err.Error())
+	} else {
+		slog.Info(msg, "error", err.Error(), "reason", reason.String())
+	}
+
+	st := status.New(code, msg+": "+errorDetail(m, err))
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason.String(),
+		Domain: errorInfoDomain,
+	})
+	if detailsErr != nil {
+		slog.Error("failed to attach error details", "error", detailsErr.Error())
+		return st.Err()
+	}
+	return withDetails.Err()
+}
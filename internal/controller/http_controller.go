@@ -1,13 +1,17 @@
 package controller
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/avran02/authentication/internal/config"
 	"github.com/avran02/authentication/internal/dto"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/repo"
 	"github.com/avran02/authentication/internal/service"
+	pb "github.com/avran02/authentication/pb"
 )
 
 type HTTPController interface {
@@ -15,23 +19,33 @@ type HTTPController interface {
 	Login(w http.ResponseWriter, r *http.Request)
 	RefreshTokens(w http.ResponseWriter, r *http.Request)
 	Logout(w http.ResponseWriter, r *http.Request)
+	GetMe(w http.ResponseWriter, r *http.Request)
+	UpdateMe(w http.ResponseWriter, r *http.Request)
+	DeleteMe(w http.ResponseWriter, r *http.Request)
+	ExportMe(w http.ResponseWriter, r *http.Request)
+	Authenticate(next http.Handler) http.Handler
+	RequireAdmin(next http.Handler) http.Handler
+	// ReloadCookieConfig applies to the cookies set from now on.
+	ReloadCookieConfig(cookieConfig config.CookieConfig)
+	AdminHTTPController
+	WebhookHTTPController
 }
 
 type httpController struct {
 	service      service.Service
-	cookieConfig config.CookieConfig
+	cookieConfig atomic.Pointer[config.CookieConfig]
 }
 
 func (c *httpController) Register(w http.ResponseWriter, r *http.Request) {
 	var req dto.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		apiError(w, http.StatusBadRequest, err)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
 		return
 	}
 
 	id, accessToken, refreshToken, expTime, err := c.service.Register(r.Context(), req.Username, req.Password, req.Email)
 	if err != nil {
-		apiError(w, http.StatusInternalServerError, err)
+		apiError(w, r, err)
 		return
 	}
 
@@ -41,23 +55,23 @@ func (c *httpController) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c.setRefreshTokenCookie(w, refreshToken, expTime)
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		apiError(w, http.StatusInternalServerError, err)
-		return
-	}
+	writeJSON(w, resp)
 }
 
 func (c *httpController) Login(w http.ResponseWriter, r *http.Request) {
 	var req dto.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		apiError(w, http.StatusBadRequest, err)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
 		return
 	}
 
-	id, accessToken, refreshToken, expTime, err := c.service.Login(r.Context(), req.Username, req.Password)
+	id, accessToken, refreshToken, expTime, err := c.service.Login(r.Context(), req.Identifier(), req.Password)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		// don't tell apart unknown users and wrong passwords
+		err = service.ErrWrongCredentials
+	}
 	if err != nil {
-		apiError(w, http.StatusInternalServerError, err)
+		apiError(w, r, err)
 		return
 	}
 
@@ -67,11 +81,7 @@ func (c *httpController) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c.setRefreshTokenCookie(w, refreshToken, expTime)
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		apiError(w, http.StatusInternalServerError, err)
-		return
-	}
+	writeJSON(w, resp)
 }
 
 func (c *httpController) RefreshTokens(w http.ResponseWriter, r *http.Request) {
@@ -84,7 +94,7 @@ func (c *httpController) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 	}
 	newAccessToken, newRefreshToken, expTime, err := c.service.RefreshTokens(r.Context(), refreshToken)
 	if err != nil {
-		apiError(w, http.StatusInternalServerError, err)
+		apiError(w, r, err)
 		return
 	}
 
@@ -93,23 +103,19 @@ func (c *httpController) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c.setRefreshTokenCookie(w, newRefreshToken, expTime)
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		apiError(w, http.StatusInternalServerError, err)
-		return
-	}
+	writeJSON(w, resp)
 }
 
 func (c *httpController) Logout(w http.ResponseWriter, r *http.Request) {
 	var req dto.LogoutRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		apiError(w, http.StatusBadRequest, err)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
 		return
 	}
 
 	ok, err := c.service.Logout(r.Context(), req.AccessToken)
 	if err != nil {
-		apiError(w, http.StatusInternalServerError, err)
+		apiError(w, r, err)
 		return
 	}
 
@@ -117,31 +123,160 @@ func (c *httpController) Logout(w http.ResponseWriter, r *http.Request) {
 		OK: ok,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		apiError(w, http.StatusInternalServerError, err)
+	writeJSON(w, resp)
+}
+
+func (c *httpController) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, err := c.service.GetUser(r.Context(), userIDFromContext(r.Context()))
+	if err != nil {
+		apiError(w, r, err)
 		return
 	}
+
+	writeJSON(w, userResponse(user))
+}
+
+func (c *httpController) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateUserRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	user, err := c.service.UpdateUser(r.Context(), userIDFromContext(r.Context()), models.UserUpdate{
+		Username:    req.Username,
+		Email:       req.Email,
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		Locale:      req.Locale,
+		Metadata:    req.Metadata,
+	})
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, userResponse(user))
+}
+
+func (c *httpController) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	var req dto.DeleteAccountRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	purgeAt, err := c.service.DeleteAccount(r.Context(), userIDFromContext(r.Context()), req.Password)
+	if errors.Is(err, service.ErrWrongCredentials) {
+		// the caller is authenticated, a 401 would make clients refresh their tokens
+		writeProblem(w, r, http.StatusForbidden, pb.ErrorReason_WRONG_CREDENTIALS, service.ErrWrongCredentials.Error(), nil)
+		return
+	}
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	resp := dto.DeleteAccountResponse{
+		OK:      true,
+		PurgeAt: purgeAt,
+	}
+
+	writeJSON(w, resp)
+}
+
+func (c *httpController) ExportMe(w http.ResponseWriter, r *http.Request) {
+	export, err := c.service.ExportUserData(r.Context(), userIDFromContext(r.Context()))
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	resp := dto.UserExportResponse{
+		ExportedAt: time.Now().UTC(),
+		Profile:    userResponse(&export.User),
+		Sessions:   make([]dto.SessionResponse, 0, len(export.Sessions)),
+	}
+	for _, session := range export.Sessions {
+		resp.Sessions = append(resp.Sessions, dto.SessionResponse{
+			AccessTokenID: session.AccessTokenID,
+			CreatedAt:     session.CreatedAt,
+		})
+	}
+	resp.AuditEvents = auditEventResponses(export.AuditEvents)
+
+	w.Header().Set("Content-Disposition", `attachment; filename="user-data.json"`)
+	writeJSON(w, resp)
+}
+
+func userResponse(user *models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		DisplayName:   user.DisplayName,
+		AvatarURL:     user.AvatarURL,
+		Locale:        user.Locale,
+		Metadata:      user.Metadata,
+		Roles:         user.Roles,
+		Status:        string(userStatus(user)),
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+func auditEventResponses(events []models.AuditEvent) []dto.AuditEventResponse {
+	resp := make([]dto.AuditEventResponse, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, dto.AuditEventResponse{
+			ID:        e.ID,
+			Type:      string(e.Type),
+			Timestamp: e.Timestamp,
+			UserID:    e.UserID,
+			ActorID:   e.ActorID,
+			IP:        e.IP,
+			UserAgent: e.UserAgent,
+			Outcome:   string(e.Outcome),
+			Reason:    e.Reason,
+			Details:   e.Details,
+		})
+	}
+	return resp
+}
+
+// userStatus reports users stored before statuses were introduced as active.
+func userStatus(user *models.User) models.UserStatus {
+	if user.Status == "" {
+		return models.StatusActive
+	}
+	return user.Status
 }
 
 func (c *httpController) setRefreshTokenCookie(w http.ResponseWriter, refreshToken string, expTime time.Time) {
+	cookieConfig := c.cookieConfig.Load()
 	cookie := http.Cookie{
 		Name:        "refreshToken",
 		Value:       refreshToken,
 		Path:        "/",
-		Domain:      c.cookieConfig.Domain,
+		Domain:      cookieConfig.Domain,
 		Expires:     expTime,
-		HttpOnly:    c.cookieConfig.HTTPOnly,
-		Secure:      c.cookieConfig.Secure,
-		SameSite:    c.cookieConfig.SameSite,
-		Partitioned: c.cookieConfig.Partitioned,
+		HttpOnly:    cookieConfig.HTTPOnly,
+		Secure:      cookieConfig.Secure,
+		SameSite:    cookieConfig.SameSiteMode(),
+		Partitioned: cookieConfig.Partitioned,
 	}
 	http.SetCookie(w, &cookie)
 }
 
+func (c *httpController) ReloadCookieConfig(cookieConfig config.CookieConfig) {
+	c.cookieConfig.Store(&cookieConfig)
+}
+
 func newHTTPController(service service.Service, cookieConfig config.CookieConfig) HTTPController {
-	return &httpController{
-		service:      service,
-		cookieConfig: cookieConfig,
+	c := &httpController{
+		service: service,
 	}
+	c.ReloadCookieConfig(cookieConfig)
+	return c
 }
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/avran02/authentication/internal/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxBodyBytes limits request bodies. The largest requests, profile updates with metadata, are a few KiB.
+const maxBodyBytes = 64 << 10
+
+var validate = newValidator()
+
+// validationError lists the fields of a request body that failed the validate tags of its dto.
+type validationError struct {
+	fields []dto.FieldError
+}
+
+func (e *validationError) Error() string {
+	msgs := make([]string, 0, len(e.fields))
+	for _, f := range e.fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return ErrValidationFailed.Error() + ": " + strings.Join(msgs, ", ")
+}
+
+func (e *validationError) Unwrap() error {
+	return ErrValidationFailed
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// report fields by their JSON names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// decodeJSON decodes the request body into v and validates it. Unknown fields, trailing data
+// and bodies over maxBodyBytes are rejected. Errors wrap ErrMalformedRequest, ErrRequestTooLarge
+// or ErrValidationFailed.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fmt.Errorf("%w: limit is %d bytes", ErrRequestTooLarge, maxBytesErr.Limit)
+		}
+		return fmt.Errorf("%w: invalid JSON body: %w", ErrMalformedRequest, err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: invalid JSON body: unexpected data after the object", ErrMalformedRequest)
+	}
+
+	return validateRequest(v)
+}
+
+func validateRequest(v any) error {
+	err := validate.Struct(v)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	fields := make([]dto.FieldError, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		// the namespace starts with the name of the request struct
+		_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+		fields = append(fields, dto.FieldError{
+			Field:   field,
+			Rule:    fieldErr.Tag(),
+			Message: fieldErrorMessage(fieldErr),
+		})
+	}
+	return &validationError{fields: fields}
+}
+
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	unit := "characters"
+	if kind := fieldErr.Kind(); kind == reflect.Slice || kind == reflect.Map {
+		unit = "items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must have at least %s %s", fieldErr.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must have at most %s %s", fieldErr.Param(), unit)
+	case "email":
+		return "must be a valid email address"
+	default:
+		return "must satisfy " + fieldErr.Tag()
+	}
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/avran02/authentication/internal/dto"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookHTTPController interface {
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeadLetters(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *httpController) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateWebhookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	events := make([]models.EventType, 0, len(req.Events))
+	for _, event := range req.Events {
+		events = append(events, models.EventType(event))
+	}
+
+	endpoint, err := c.service.CreateWebhookEndpoint(r.Context(), req.URL, req.Secret, events)
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	resp := webhookResponse(endpoint)
+	resp.Secret = endpoint.Secret
+	writeJSON(w, resp)
+}
+
+func (c *httpController) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := c.service.ListWebhookEndpoints(r.Context())
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	resp := dto.ListWebhooksResponse{Webhooks: make([]dto.WebhookResponse, 0, len(endpoints))}
+	for i := range endpoints {
+		resp.Webhooks = append(resp.Webhooks, webhookResponse(&endpoints[i]))
+	}
+
+	writeJSON(w, resp)
+}
+
+func (c *httpController) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := c.service.DeleteWebhookEndpoint(r.Context(), chi.URLParam(r, "id")); err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	writeJSON(w, dto.OKResponse{OK: true})
+}
+
+func (c *httpController) ListWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	deadLetters, total, err := c.service.ListWebhookDeadLetters(r.Context(), page, limit)
+	if err != nil {
+		apiError(w, r, err)
+		return
+	}
+
+	resp := dto.ListWebhookDeadLettersResponse{
+		DeadLetters: make([]dto.WebhookDeadLetterResponse, 0, len(deadLetters)),
+		Total:       total,
+	}
+	for _, d := range deadLetters {
+		resp.DeadLetters = append(resp.DeadLetters, dto.WebhookDeadLetterResponse{
+			ID:         d.ID,
+			EndpointID: d.EndpointID,
+			URL:        d.URL,
+			Event: dto.WebhookEventResponse{
+				ID:        d.Event.ID,
+				Type:      string(d.Event.Type),
+				Timestamp: d.Event.Timestamp,
+				UserID:    d.Event.UserID,
+				Data:      d.Event.Data,
+			},
+			Attempts:  d.Attempts,
+			LastError: d.LastError,
+			FailedAt:  d.FailedAt,
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+func webhookResponse(endpoint *models.WebhookEndpoint) dto.WebhookResponse {
+	events := make([]string, 0, len(endpoint.Events))
+	for _, event := range endpoint.Events {
+		events = append(events, string(event))
+	}
+	return dto.WebhookResponse{
+		ID:        endpoint.ID,
+		URL:       endpoint.URL,
+		Events:    events,
+		CreatedAt: endpoint.CreatedAt,
+	}
+}
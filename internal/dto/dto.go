@@ -1,9 +1,11 @@
 package dto
 
+import "time"
+
 type RegisterRequest struct {
-	Username string  `json:"username"`
-	Password string  `json:"password"`
-	Email    *string `json:"email,omitempty"`
+	Username string  `json:"username" validate:"required,max=64,excludes=@"`
+	Password string  `json:"password" validate:"required,max=128"`
+	Email    *string `json:"email,omitempty" validate:"omitempty,email,max=254"`
 }
 
 type RegisterResponse struct {
@@ -11,9 +13,19 @@ type RegisterResponse struct {
 	AccessToken string `json:"accessToken"`
 }
 
+// LoginRequest identifies the user by Login, which may be a username or an email.
+// Username is kept for clients written before email logins were supported.
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Login    string `json:"login" validate:"required_without=Username,max=254"`
+	Username string `json:"username" validate:"max=64"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+func (r LoginRequest) Identifier() string {
+	if r.Login != "" {
+		return r.Login
+	}
+	return r.Username
 }
 
 type LoginResponse struct {
@@ -26,9 +38,164 @@ type RefreshTokenResponse struct {
 }
 
 type LogoutRequest struct {
-	AccessToken string `json:"accessToken"`
+	AccessToken string `json:"accessToken" validate:"required"`
 }
 
 type LogoutResponse struct {
 	OK bool `json:"ok"`
 }
+
+type UserResponse struct {
+	ID            string         `json:"id"`
+	Username      string         `json:"username"`
+	Email         *string        `json:"email,omitempty"`
+	EmailVerified bool           `json:"emailVerified"`
+	DisplayName   string         `json:"displayName,omitempty"`
+	AvatarURL     string         `json:"avatarURL,omitempty"`
+	Locale        string         `json:"locale,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	Roles         []string       `json:"roles,omitempty"`
+	Status        string         `json:"status"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// UpdateUserRequest is a partial update, omitted fields are left untouched.
+// Empty AvatarURL and Locale clear the field.
+type UpdateUserRequest struct {
+	Username    *string        `json:"username,omitempty" validate:"omitempty,min=1,max=64,excludes=@"`
+	Email       *string        `json:"email,omitempty" validate:"omitempty,email,max=254"`
+	DisplayName *string        `json:"displayName,omitempty" validate:"omitempty,max=128"`
+	AvatarURL   *string        `json:"avatarURL,omitempty" validate:"omitempty,max=2048"`
+	Locale      *string        `json:"locale,omitempty" validate:"omitempty,max=35"`
+	Metadata    map[string]any `json:"metadata,omitempty" validate:"max=32"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+type DeleteAccountResponse struct {
+	OK      bool      `json:"ok"`
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+type SessionResponse struct {
+	AccessTokenID string    `json:"accessTokenID"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type UserExportResponse struct {
+	ExportedAt  time.Time            `json:"exportedAt"`
+	Profile     UserResponse         `json:"profile"`
+	Sessions    []SessionResponse    `json:"sessions"`
+	AuditEvents []AuditEventResponse `json:"auditEvents"`
+}
+
+type AuditEventResponse struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	UserID    string         `json:"userId,omitempty"`
+	ActorID   string         `json:"actorId,omitempty"`
+	IP        string         `json:"ip,omitempty"`
+	UserAgent string         `json:"userAgent,omitempty"`
+	Outcome   string         `json:"outcome"`
+	Reason    string         `json:"reason,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type ListAuditEventsResponse struct {
+	Events []AuditEventResponse `json:"events"`
+	Total  int64                `json:"total"`
+}
+
+type OKResponse struct {
+	OK bool `json:"ok"`
+}
+
+type ListUsersResponse struct {
+	Users []UserResponse `json:"users"`
+	Total int64          `json:"total"`
+}
+
+// ResetPasswordRequest may omit Password to generate a temporary one.
+type ResetPasswordRequest struct {
+	Password string `json:"password,omitempty" validate:"omitempty,max=128"`
+}
+
+type ResetPasswordResponse struct {
+	Password string `json:"password"`
+}
+
+type SetStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+// SetRolesRequest replaces all roles, an empty list removes them.
+type SetRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,max=16,dive,required,max=64"`
+}
+
+// CreateWebhookRequest may omit Secret to generate one and Events to subscribe to all events.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,max=2048"`
+	Secret string   `json:"secret,omitempty" validate:"omitempty,min=16,max=256"`
+	Events []string `json:"events,omitempty" validate:"dive,required"`
+}
+
+// WebhookResponse only includes Secret in the response to CreateWebhookRequest.
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type WebhookEventResponse struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	UserID    string         `json:"userId,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+type WebhookDeadLetterResponse struct {
+	ID         string               `json:"id"`
+	EndpointID string               `json:"endpointId"`
+	URL        string               `json:"url"`
+	Event      WebhookEventResponse `json:"event"`
+	Attempts   int                  `json:"attempts"`
+	LastError  string               `json:"lastError"`
+	FailedAt   time.Time            `json:"failedAt"`
+}
+
+type ListWebhookDeadLettersResponse struct {
+	DeadLetters []WebhookDeadLetterResponse `json:"deadLetters"`
+	Total       int64                       `json:"total"`
+}
+
+// Problem is an RFC 7807 error response. Code is a stable machine readable reason.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+	// Errors lists the invalid fields of a request body.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a failed validation rule of a request field. Field is the JSON path, like "roles[0]".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
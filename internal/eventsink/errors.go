@@ -0,0 +1,5 @@
+package eventsink
+
+import "errors"
+
+var ErrUnknownSink = errors.New("unknown event sink")
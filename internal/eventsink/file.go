@@ -0,0 +1,51 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+)
+
+// fileSink writes events as JSON lines, for local development.
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newFileSink(conf config.FileSink) (*fileSink, error) {
+	if conf.Path == "-" {
+		return &fileSink{file: os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(conf.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:mnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &fileSink{file: f}, nil
+}
+
+func (s *fileSink) Publish(_ context.Context, event models.Event, _ int) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	if s.file == os.Stdout {
+		return nil
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,42 @@
+package eventsink_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/eventsink"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := eventsink.New(config.Outbox{Sink: "file", File: config.FileSink{Path: path}}, nil)
+	assert.NoError(t, err)
+
+	for _, id := range []string{"1", "2"} {
+		err = sink.Publish(context.Background(), models.Event{ID: id, Type: models.EventUserLoggedIn, UserID: "user123"}, 1)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+
+	var event models.Event
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "2", event.ID)
+	assert.Equal(t, models.EventUserLoggedIn, event.Type)
+}
+
+func TestNew_UnknownSink(t *testing.T) {
+	_, err := eventsink.New(config.Outbox{Sink: "carrier-pigeon"}, nil)
+	assert.ErrorIs(t, err, eventsink.ErrUnknownSink)
+}
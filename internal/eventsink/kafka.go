@@ -0,0 +1,50 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaSink keys messages by user, so events of one user land in the same partition.
+// The event ID is sent in the idempotency-key header.
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(conf config.KafkaSink) *kafkaSink {
+	return &kafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(conf.Brokers...),
+		Topic:        conf.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}}
+}
+
+func (s *kafkaSink) Publish(ctx context.Context, event models.Event, _ int) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.UserID),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "idempotency-key", Value: []byte(event.ID)},
+			{Key: "event-type", Value: []byte(event.Type)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	return nil
+}
+
+func (s *kafkaSink) Close() error {
+	return s.writer.Close()
+}
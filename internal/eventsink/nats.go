@@ -0,0 +1,51 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/nats-io/nats.go"
+)
+
+// natsSink publishes to JetStream, which acknowledges stored messages and drops
+// duplicates with the same Nats-Msg-Id within the duplicate window of the stream.
+type natsSink struct {
+	conn          *nats.Conn
+	js            nats.JetStreamContext
+	subjectPrefix string
+}
+
+func newNATSSink(conf config.NATSSink) (*natsSink, error) {
+	conn, err := nats.Connect(conf.URL, nats.Name("auth-service"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &natsSink{conn: conn, js: js, subjectPrefix: conf.SubjectPrefix}, nil
+}
+
+func (s *natsSink) Publish(ctx context.Context, event models.Event, _ int) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := nats.NewMsg(s.subjectPrefix + "." + string(event.Type))
+	msg.Data = body
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	if _, err = s.js.PublishMsg(msg, nats.Context(ctx)); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+func (s *natsSink) Close() error {
+	return s.conn.Drain()
+}
@@ -0,0 +1,47 @@
+package eventsink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/webhook"
+)
+
+// Sink publishes events from the outbox. Publish returns after the event was accepted by the destination.
+// attempt counts the publications of the event, starting at 1.
+// Events may be published more than once, consumers drop duplicates by the event ID.
+type Sink interface {
+	Publish(ctx context.Context, event models.Event, attempt int) error
+	Close() error
+}
+
+// New creates the sink selected in the config. Webhook events are delivered by webhooks.
+func New(conf config.Outbox, webhooks *webhook.Dispatcher) (Sink, error) {
+	switch conf.Sink {
+	case "webhook":
+		return &webhookSink{dispatcher: webhooks}, nil
+	case "nats":
+		return newNATSSink(conf.NATS)
+	case "kafka":
+		return newKafkaSink(conf.Kafka), nil
+	case "file":
+		return newFileSink(conf.File)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSink, conf.Sink)
+	}
+}
+
+type webhookSink struct {
+	dispatcher *webhook.Dispatcher
+}
+
+func (s *webhookSink) Publish(ctx context.Context, event models.Event, attempt int) error {
+	return s.dispatcher.Publish(ctx, event, attempt)
+}
+
+// Close leaves stopping the dispatcher to its owner.
+func (s *webhookSink) Close() error {
+	return nil
+}
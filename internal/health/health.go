@@ -0,0 +1,126 @@
+// Package health checks the dependencies of the service for liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// checkTimeout bounds a single check, so a hanging dependency reports a failure instead of blocking the probe.
+const checkTimeout = 2 * time.Second
+
+// CheckFunc returns an error if the dependency isn't usable.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Report is the result of all checks. It is served without authentication, so Checks only
+// holds StatusOK or StatusFail, the errors are logged.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Checker runs the registered checks. It reports not ready once shutdown started,
+// so load balancers stop routing traffic before the servers are drained.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Register adds a check named name. It must be called before the checker is used.
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// SetShuttingDown makes all following readiness checks fail.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs all checks concurrently. The report is ok only if every check passed.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(checks)+1)}
+	if c.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = StatusFail
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			err := ch.fn(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				slog.Warn("health check failed", "check", ch.name, "error", err.Error())
+				report.Status = StatusFail
+				report.Checks[ch.name] = StatusFail
+				return
+			}
+			report.Checks[ch.name] = StatusOK
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Ready reports whether every check passed.
+func (c *Checker) Ready(ctx context.Context) bool {
+	return c.Check(ctx).Status == StatusOK
+}
+
+// LivenessHandler answers 200 as long as the process serves HTTP. It doesn't check dependencies,
+// a restart wouldn't fix an unreachable database.
+func LivenessHandler(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// ReadinessHandler answers 200 if every check passed and 503 with the failed checks otherwise.
+func (c *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed to write health report", "error", err.Error())
+	}
+}
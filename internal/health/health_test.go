@@ -0,0 +1,57 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avran02/authentication/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func readiness(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	checker.ReadinessHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	return w.Code, report
+}
+
+func TestReadinessHandler(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Register("mongo", func(context.Context) error { return nil })
+
+	code, report := readiness(t, checker)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["mongo"])
+
+	checker.Register("jwt", func(context.Context) error { return errors.New("no key") })
+	code, report = readiness(t, checker)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["mongo"])
+	assert.Equal(t, health.StatusFail, report.Checks["jwt"])
+}
+
+func TestReadinessFailsOnShutdown(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Register("mongo", func(context.Context) error { return nil })
+	checker.SetShuttingDown()
+
+	code, report := readiness(t, checker)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.StatusFail, report.Checks["shutdown"])
+}
+
+func TestLivenessHandlerIgnoresChecks(t *testing.T) {
+	w := httptest.NewRecorder()
+	health.LivenessHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
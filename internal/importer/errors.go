@@ -0,0 +1,13 @@
+package importer
+
+import "errors"
+
+var (
+	ErrUnknownFormat    = errors.New("unknown import format")
+	ErrUnknownAlgorithm = errors.New("unknown hash algorithm")
+	ErrMissingField     = errors.New("missing required field")
+	ErrMalformedHash    = errors.New("malformed password hash")
+	ErrInvalidEmail     = errors.New("email is invalid")
+
+	errUserExists = errors.New("user exists")
+)
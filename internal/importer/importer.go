@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/hasher"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/google/uuid"
+)
+
+type Options struct {
+	Format   string
+	Firebase hasher.FirebaseScryptParams
+}
+
+type Result struct {
+	Imported int
+	Skipped  int
+	Failed   int
+}
+
+// Importer creates users with password hashes from other systems. The hashes are
+// kept as is and replaced with the native algorithm on the first successful login.
+type Importer interface {
+	Import(ctx context.Context, r io.Reader, opts Options) (Result, error)
+}
+
+type importer struct {
+	repo repo.Repo
+}
+
+func (i *importer) Import(ctx context.Context, r io.Reader, opts Options) (Result, error) {
+	var res Result
+	err := readRecords(r, opts.Format, func(line int, rec *Record, err error) {
+		if err == nil {
+			err = i.importRecord(ctx, rec, opts)
+		}
+
+		switch {
+		case errors.Is(err, errUserExists):
+			slog.Info("user already exists, skipping", "line", line, "username", rec.Username)
+			res.Skipped++
+		case err != nil:
+			slog.Error("failed to import user", "line", line, "error", err.Error())
+			res.Failed++
+		default:
+			res.Imported++
+		}
+	})
+	if err != nil {
+		return res, fmt.Errorf("importer.Import: %w", err)
+	}
+
+	return res, nil
+}
+
+func (i *importer) importRecord(ctx context.Context, rec *Record, opts Options) error {
+	if rec.Username == "" {
+		return fmt.Errorf("%w: username", ErrMissingField)
+	}
+
+	if err := rec.validateEmail(); err != nil {
+		return err
+	}
+	passwordHash, err := rec.passwordHash(opts.Firebase)
+	if err != nil {
+		return err
+	}
+
+	user, err := i.repo.FindUserByUsername(ctx, rec.Username)
+	if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user != nil {
+		return errUserExists
+	}
+
+	id := rec.ID
+	if id == "" {
+		id = uuid.NewString()
+	}
+	now := time.Now().UTC()
+	err = i.repo.CreateUser(ctx, models.User{
+		ID:        id,
+		Email:     rec.Email,
+		Username:  rec.Username,
+		Password:  passwordHash,
+		Status:    models.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	switch {
+	case errors.Is(err, repo.ErrDuplicateUser):
+		// the id or email is taken, or the username was taken since the lookup
+		return errUserExists
+	case err != nil:
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+func New(repo repo.Repo) Importer {
+	return &importer{
+		repo: repo,
+	}
+}
@@ -0,0 +1,253 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/avran02/authentication/internal/pkg/hasher"
+)
+
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// Record is a single user exported from another system.
+// Hash and Salt are base64 encoded unless HashEncoding is "hex".
+type Record struct {
+	ID           string  `json:"id"`
+	Username     string  `json:"username"`
+	Email        *string `json:"email,omitempty"`
+	Algorithm    string  `json:"algorithm"`
+	Hash         string  `json:"hash"`
+	Salt         string  `json:"salt,omitempty"`
+	HashEncoding string  `json:"hashEncoding,omitempty"`
+	Iterations   int     `json:"iterations,omitempty"`
+	Rounds       int     `json:"rounds,omitempty"`
+	MemCost      int     `json:"memCost,omitempty"`
+	Parallelism  int     `json:"parallelism,omitempty"`
+	SaltPosition string  `json:"saltPosition,omitempty"`
+}
+
+// readRecords calls fn for every record of r. Malformed lines are reported to fn with a nil record.
+func readRecords(r io.Reader, format string, fn func(line int, rec *Record, err error)) error {
+	switch format {
+	case FormatJSONL:
+		return readJSONL(r, fn)
+	case FormatCSV:
+		return readCSV(r, fn)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+func readJSONL(r io.Reader, fn func(line int, rec *Record, err error)) error {
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var rec Record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err != nil {
+			fn(line, nil, err)
+			continue
+		}
+		fn(line, &rec, nil)
+	}
+}
+
+func readCSV(r io.Reader, fn func(line int, rec *Record, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			fn(line, nil, err)
+			continue
+		}
+
+		rec, err := csvRecord(header, row)
+		if err != nil {
+			fn(line, nil, err)
+			continue
+		}
+		fn(line, rec, nil)
+	}
+}
+
+func csvRecord(header, row []string) (*Record, error) {
+	var rec Record
+	var err error
+	for i, column := range header {
+		if i >= len(row) || row[i] == "" {
+			continue
+		}
+		value := row[i]
+		switch strings.TrimSpace(column) {
+		case "id":
+			rec.ID = value
+		case "username":
+			rec.Username = value
+		case "email":
+			rec.Email = &value
+		case "algorithm":
+			rec.Algorithm = value
+		case "hash":
+			rec.Hash = value
+		case "salt":
+			rec.Salt = value
+		case "hashEncoding":
+			rec.HashEncoding = value
+		case "iterations":
+			rec.Iterations, err = strconv.Atoi(value)
+		case "rounds":
+			rec.Rounds, err = strconv.Atoi(value)
+		case "memCost":
+			rec.MemCost, err = strconv.Atoi(value)
+		case "parallelism":
+			rec.Parallelism, err = strconv.Atoi(value)
+		case "saltPosition":
+			rec.SaltPosition = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad %s: %w", column, err)
+		}
+	}
+	return &rec, nil
+}
+
+// maxEmailLength is the limit the service puts on the emails of new users.
+const maxEmailLength = 254
+
+// validateEmail checks the email of rec like the service checks the emails of new users.
+func (rec *Record) validateEmail() error {
+	if rec.Email == nil {
+		return nil
+	}
+	email := *rec.Email
+	if len(email) > maxEmailLength {
+		return ErrInvalidEmail
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+// passwordHash converts the foreign hash of rec to the PHC string understood by hasher.Hasher.
+// Hashes with parameters beyond the limits of the hasher are rejected, they could never be verified.
+func (rec *Record) passwordHash(firebase hasher.FirebaseScryptParams) (string, error) {
+	encoded, err := rec.encodedHash(firebase)
+	if err != nil {
+		return "", err
+	}
+	if err = hasher.Validate(encoded); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	return encoded, nil
+}
+
+func (rec *Record) encodedHash(firebase hasher.FirebaseScryptParams) (string, error) {
+	if rec.Hash == "" {
+		return "", fmt.Errorf("%w: hash", ErrMissingField)
+	}
+
+	switch strings.ToLower(rec.Algorithm) {
+	case hasher.Bcrypt:
+		if !strings.HasPrefix(rec.Hash, "$2") {
+			return "", ErrMalformedHash
+		}
+		return rec.Hash, nil
+	case hasher.Argon2id:
+		if !strings.HasPrefix(rec.Hash, "$argon2id$") {
+			return "", ErrMalformedHash
+		}
+		return rec.Hash, nil
+	case hasher.Scrypt:
+		if strings.HasPrefix(rec.Hash, "$scrypt$") {
+			return rec.Hash, nil
+		}
+		return rec.encode(func(salt, key []byte) string {
+			return hasher.ScryptHash(orDefault(rec.MemCost, 14), orDefault(rec.Rounds, 8), orDefault(rec.Parallelism, 1), salt, key) //nolint:mnd
+		})
+	case hasher.PBKDF2SHA256:
+		if strings.HasPrefix(rec.Hash, "$pbkdf2-sha256$") {
+			return rec.Hash, nil
+		}
+		if rec.Iterations == 0 {
+			return "", fmt.Errorf("%w: iterations", ErrMissingField)
+		}
+		return rec.encode(func(salt, key []byte) string {
+			return hasher.PBKDF2SHA256Hash(rec.Iterations, salt, key)
+		})
+	case hasher.FirebaseScrypt:
+		if len(firebase.SignerKey) == 0 {
+			return "", fmt.Errorf("%w: firebase signer key", ErrMissingField)
+		}
+		firebase.Rounds = orDefault(rec.Rounds, firebase.Rounds)
+		firebase.MemCost = orDefault(rec.MemCost, firebase.MemCost)
+		return rec.encode(func(salt, key []byte) string {
+			return hasher.FirebaseScryptHash(firebase, salt, key)
+		})
+	case "sha1", "sha256", "sha512":
+		position := rec.SaltPosition
+		if position == "" {
+			position = hasher.SaltPrefix
+		}
+		if position != hasher.SaltPrefix && position != hasher.SaltSuffix {
+			return "", fmt.Errorf("%w: bad salt position %q", ErrMalformedHash, position)
+		}
+		return rec.encode(func(salt, sum []byte) string {
+			return hasher.SaltedSHAHash("salted-"+strings.ToLower(rec.Algorithm), position, salt, sum)
+		})
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownAlgorithm, rec.Algorithm)
+	}
+}
+
+func (rec *Record) encode(format func(salt, key []byte) string) (string, error) {
+	salt, err := rec.decode(rec.Salt)
+	if err != nil {
+		return "", fmt.Errorf("%w: bad salt: %w", ErrMalformedHash, err)
+	}
+	key, err := rec.decode(rec.Hash)
+	if err != nil {
+		return "", fmt.Errorf("%w: bad hash: %w", ErrMalformedHash, err)
+	}
+	return format(salt, key), nil
+}
+
+func (rec *Record) decode(s string) ([]byte, error) {
+	if strings.EqualFold(rec.HashEncoding, "hex") {
+		return hex.DecodeString(s)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func orDefault(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRecords_CSV(t *testing.T) {
+	sum := sha1.Sum([]byte("secret" + "salt")) //nolint:gosec
+	input := "username,email,algorithm,hash,salt,hashEncoding,saltPosition\n" +
+		"john,john@example.com,sha1," + hex.EncodeToString(sum[:]) + "," + hex.EncodeToString([]byte("salt")) + ",hex,suffix\n" +
+		"broken,,md5,abc,,,\n"
+
+	var records []*Record
+	err := readRecords(strings.NewReader(input), FormatCSV, func(_ int, rec *Record, err error) {
+		assert.NoError(t, err)
+		records = append(records, rec)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "john@example.com", *records[0].Email)
+
+	encoded, err := records[0].passwordHash(hasher.FirebaseScryptParams{})
+	assert.NoError(t, err)
+	ok, needsRehash, err := hasher.New(config.Hasher{Algorithm: hasher.Bcrypt, Bcrypt: config.Bcrypt{Cost: 4}}).Verify("secret", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	_, err = records[1].passwordHash(hasher.FirebaseScryptParams{})
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
+
+func TestReadRecords_JSONL(t *testing.T) {
+	input := `{"username":"jane","algorithm":"bcrypt","hash":"$2a$04$abcdefghijklmnopqrstuu5Y1nxlY6VtT1Fq1jJ2lY3uKzWrFEzC."}` + "\n" +
+		`{"username":"joe","algorithm":"pbkdf2-sha256","hash":"AAAA","salt":"AAAA"}` + "\n"
+
+	var records []*Record
+	err := readRecords(strings.NewReader(input), FormatJSONL, func(_ int, rec *Record, err error) {
+		assert.NoError(t, err)
+		records = append(records, rec)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	encoded, err := records[0].passwordHash(hasher.FirebaseScryptParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, records[0].Hash, encoded)
+
+	_, err = records[1].passwordHash(hasher.FirebaseScryptParams{})
+	assert.ErrorIs(t, err, ErrMissingField)
+}
+
+func TestPasswordHash_RejectsUnsafeParameters(t *testing.T) {
+	for _, rec := range []Record{
+		{Algorithm: "argon2id", Hash: "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$aGFzaGhhc2g"},
+		{Algorithm: "argon2id", Hash: "$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdA$aGFzaGhhc2g"},
+		{Algorithm: "scrypt", Hash: "$scrypt$ln=63,r=8,p=1$c2FsdA$aGFzaGhhc2g"},
+		{Algorithm: "pbkdf2-sha256", Hash: "$pbkdf2-sha256$i=0$c2FsdA$aGFzaGhhc2g"},
+		{Algorithm: "scrypt", Hash: "AAAA", Salt: "AAAA", MemCost: 40},
+	} {
+		_, err := rec.passwordHash(hasher.FirebaseScryptParams{})
+		assert.ErrorIs(t, err, ErrMalformedHash, rec.Hash)
+		assert.ErrorIs(t, err, hasher.ErrMalformedHash, rec.Hash)
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	email := func(s string) *string { return &s }
+	tests := []struct {
+		name  string
+		email *string
+		err   error
+	}{
+		{"none", nil, nil},
+		{"valid", email("john@example.com"), nil},
+		{"malformed", email("john"), ErrInvalidEmail},
+		{"with name", email("John <john@example.com>"), ErrInvalidEmail},
+		{"too long", email(strings.Repeat("a", 250) + "@example.com"), ErrInvalidEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Record{Username: "john", Email: tt.email}
+			assert.ErrorIs(t, rec.validateEmail(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor counts and times unary calls by method and status code.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeGrpc(info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamServerInterceptor counts and times streams by method and status code.
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeGrpc(info.FullMethod, start, err)
+	return err
+}
+
+func observeGrpc(method string, start time.Time, err error) {
+	GrpcRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	Since(GrpcRequestDuration.WithLabelValues(method), start)
+}
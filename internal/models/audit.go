@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type AuditEventType string
+
+const (
+	AuditRegister       AuditEventType = "register"
+	AuditLogin          AuditEventType = "login"
+	AuditRefresh        AuditEventType = "refresh"
+	AuditLogout         AuditEventType = "logout"
+	AuditPasswordChange AuditEventType = "password_change"
+	AuditProfileUpdate  AuditEventType = "profile_update"
+	AuditAccountDelete  AuditEventType = "account_delete"
+	AuditAdminAction    AuditEventType = "admin_action"
+)
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditEvent is an entry of the append-only authentication audit trail.
+// Events are hash chained: Hash covers the event content and PrevHash, the hash of the event with Seq-1.
+type AuditEvent struct {
+	Seq       int64
+	PrevHash  string
+	Hash      string
+	ID        string
+	Type      AuditEventType
+	Timestamp time.Time
+	// UserID is the user the event is about. ActorID differs from it for admin actions.
+	UserID    string
+	ActorID   string
+	IP        string
+	UserAgent string
+	Outcome   AuditOutcome
+	// Reason explains failures.
+	Reason  string
+	Details map[string]any
+}
+
+type AuditFilter struct {
+	UserID  string
+	Type    AuditEventType
+	Outcome AuditOutcome
+	From    time.Time
+	To      time.Time
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventUserRegistered  EventType = "user.registered"
+	EventUserLoggedIn    EventType = "user.logged_in"
+	EventSessionRevoked  EventType = "session.revoked"
+	EventPasswordChanged EventType = "password.changed"
+)
+
+var EventTypes = []EventType{
+	EventUserRegistered,
+	EventUserLoggedIn,
+	EventSessionRevoked,
+	EventPasswordChanged,
+}
+
+func (t EventType) IsValid() bool {
+	return slices.Contains(EventTypes, t)
+}
+
+// Event is a domain event published to webhooks and message brokers.
+// ID is the idempotency key: it stays the same across delivery attempts, so consumers can use it to drop duplicates.
+type Event struct {
+	ID        string         `json:"id"`
+	Type      EventType      `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	UserID    string         `json:"userId"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// OutboxRecord is an event waiting in the transactional outbox to be published.
+type OutboxRecord struct {
+	// ID equals Event.ID.
+	ID            string
+	Event         Event
+	CreatedAt     time.Time
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	// LockedUntil keeps other dispatchers from publishing the record while it is being published.
+	LockedUntil time.Time
+	PublishedAt *time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Migration is a versioned change of the database schema, like creating indexes.
+// AppliedAt is nil while the migration is pending.
+type Migration struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Revocation records that all sessions of a user issued before RevokedAt were revoked.
+// Seq increases by one with every revocation and serves as the cursor of the revocation feed.
+type Revocation struct {
+	Seq       int64
+	UserID    string
+	Reason    string
+	RevokedAt time.Time
+}
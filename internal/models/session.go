@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Session is a stored refresh token. The token itself is only kept as a hash.
+type Session struct {
+	UserID        string
+	AccessTokenID string
+	CreatedAt     time.Time
+}
+
+// UserExport is everything stored about a user.
+type UserExport struct {
+	User        User
+	Sessions    []Session
+	AuditEvents []AuditEvent
+}
@@ -3,6 +3,7 @@ package models
 import "github.com/golang-jwt/jwt/v5"
 
 type AccessTokenClaims struct {
+	Roles []string `json:"roles,omitempty"`
 	jwt.RegisteredClaims
 }
 
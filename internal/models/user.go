@@ -1,8 +1,85 @@
 package models
 
+import (
+	"slices"
+	"time"
+)
+
+const RoleAdmin = "admin"
+
+// RoleRevocationsReader lets service accounts watch the revocation feed.
+const RoleRevocationsReader = "revocations_reader"
+
+type UserStatus string
+
+// Users stored before statuses were introduced have an empty status and are treated as active.
+const (
+	StatusActive              UserStatus = "active"
+	StatusDisabled            UserStatus = "disabled"
+	StatusLocked              UserStatus = "locked"
+	StatusPendingVerification UserStatus = "pending_verification"
+)
+
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case StatusActive, StatusDisabled, StatusLocked, StatusPendingVerification:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
-	ID       string
-	Email    *string
-	Username string
-	Password string
+	ID            string
+	Email         *string
+	EmailVerified bool
+	Username      string
+	Password      string
+	DisplayName   string
+	AvatarURL     string
+	Locale        string
+	Metadata      map[string]any
+	Roles         []string
+	Status        UserStatus
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// DeletedAt is set when the user deleted the account. The user is purged once the grace period is over.
+	DeletedAt *time.Time
+}
+
+func (u *User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// UserUpdate holds the profile fields a user may change. Nil fields are left untouched.
+type UserUpdate struct {
+	Username    *string
+	Email       *string
+	DisplayName *string
+	AvatarURL   *string
+	Locale      *string
+	Metadata    map[string]any
+}
+
+// UserChanges holds the user fields to set in the database. Nil fields are left untouched,
+// so concurrent changes of other fields, like a password rehash, aren't overwritten.
+type UserChanges struct {
+	Username      *string
+	Email         *string
+	EmailVerified *bool
+	Password      *string
+	DisplayName   *string
+	AvatarURL     *string
+	Locale        *string
+	Metadata      map[string]any
+	Roles         []string
+	Status        *UserStatus
+	UpdatedAt     time.Time
+}
+
+// UserFilter narrows down the users listed by admins. Query matches username, email and display name.
+type UserFilter struct {
+	Query  string
+	Role   string
+	Status UserStatus
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+type WebhookEndpoint struct {
+	ID     string
+	URL    string
+	Secret string
+	// Events the endpoint is subscribed to. Empty means all events.
+	Events    []EventType
+	CreatedAt time.Time
+}
+
+func (e WebhookEndpoint) Subscribed(eventType EventType) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, eventType)
+}
+
+// WebhookDeadLetter is a delivery that failed after all retries.
+type WebhookDeadLetter struct {
+	ID         string
+	EndpointID string
+	URL        string
+	Event      Event
+	Attempts   int
+	LastError  string
+	FailedAt   time.Time
+}
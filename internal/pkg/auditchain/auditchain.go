@@ -0,0 +1,131 @@
+package auditchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+)
+
+// Link sets the chain fields of event so that it follows prev. prev is nil for the first event.
+func Link(event models.AuditEvent, prev *models.AuditEvent) (models.AuditEvent, error) {
+	event.Seq = 1
+	event.PrevHash = ""
+	if prev != nil {
+		event.Seq = prev.Seq + 1
+		event.PrevHash = prev.Hash
+	}
+	// stored timestamps only have millisecond precision
+	event.Timestamp = event.Timestamp.UTC().Truncate(time.Millisecond)
+	hash, err := Hash(event)
+	if err != nil {
+		return models.AuditEvent{}, err
+	}
+	event.Hash = hash
+	return event, nil
+}
+
+// Hash returns the hex encoded SHA-256 of the event content, including the hash of the previous event.
+func Hash(event models.AuditEvent) (string, error) {
+	payload, err := json.Marshal(struct {
+		Seq       int64                 `json:"seq"`
+		PrevHash  string                `json:"prevHash"`
+		ID        string                `json:"id"`
+		Type      models.AuditEventType `json:"type"`
+		Timestamp string                `json:"timestamp"`
+		UserID    string                `json:"userId"`
+		ActorID   string                `json:"actorId"`
+		IP        string                `json:"ip"`
+		UserAgent string                `json:"userAgent"`
+		Outcome   models.AuditOutcome   `json:"outcome"`
+		Reason    string                `json:"reason"`
+		Details   map[string]any        `json:"details"`
+	}{
+		Seq:       event.Seq,
+		PrevHash:  event.PrevHash,
+		ID:        event.ID,
+		Type:      event.Type,
+		Timestamp: event.Timestamp.UTC().Format(time.RFC3339Nano),
+		UserID:    event.UserID,
+		ActorID:   event.ActorID,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		Outcome:   event.Outcome,
+		Reason:    event.Reason,
+		Details:   event.Details,
+	})
+	if err != nil {
+		return "", fmt.Errorf("auditchain: can't marshal audit event %s: %w", event.ID, err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verifier checks events one by one in sequence order.
+type Verifier struct {
+	prev    *models.AuditEvent
+	checked int64
+	// head is the seq and hash of the newest event the writers recorded, see ExpectHead.
+	headSeq  int64
+	headHash string
+}
+
+// ExpectHead makes Next and Finish check that the chain reaches the event with seq and hash, the
+// head recorded by the writers before the walk started, so removing the newest events is detected.
+// Events appended during the walk are verified like the others. A zero seq expects nothing.
+func (v *Verifier) ExpectHead(seq int64, hash string) {
+	v.headSeq, v.headHash = seq, hash
+}
+
+// Next verifies event against its own content and the previously seen event.
+func (v *Verifier) Next(event models.AuditEvent) error {
+	expectedSeq := int64(1)
+	if v.prev != nil {
+		expectedSeq = v.prev.Seq + 1
+	}
+
+	hash, err := Hash(event)
+	switch {
+	case event.Seq != expectedSeq:
+		err = ErrSequenceGap
+	case v.prev != nil && event.PrevHash != v.prev.Hash, v.prev == nil && event.PrevHash != "":
+		err = ErrPrevHashMismatch
+	case err != nil:
+	case hash != event.Hash:
+		err = ErrHashMismatch
+	case event.Seq == v.headSeq && event.Hash != v.headHash:
+		err = ErrHeadMismatch
+	}
+	if err != nil {
+		return &BrokenLinkError{Seq: event.Seq, EventID: event.ID, Err: err}
+	}
+
+	v.prev = &event
+	v.checked++
+	return nil
+}
+
+// Finish reports the events removed from the end of the chain, after the last call to Next.
+func (v *Verifier) Finish() error {
+	var seq int64
+	if v.prev != nil {
+		seq = v.prev.Seq
+	}
+	if seq < v.headSeq {
+		return &BrokenLinkError{Seq: seq + 1, Err: fmt.Errorf("%w: the head is at seq %d", ErrEventsRemoved, v.headSeq)}
+	}
+	return nil
+}
+
+func (v *Verifier) Checked() int64 {
+	return v.checked
+}
+
+// Head is the last verified event, nil if none were verified.
+func (v *Verifier) Head() *models.AuditEvent {
+	return v.prev
+}
@@ -0,0 +1,139 @@
+package auditchain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/auditchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func chain(t *testing.T, n int) []models.AuditEvent {
+	t.Helper()
+	events := make([]models.AuditEvent, 0, n)
+	var prev *models.AuditEvent
+	for i := range n {
+		event, err := auditchain.Link(models.AuditEvent{
+			ID:        string(rune('a' + i)),
+			Type:      models.AuditLogin,
+			Timestamp: time.Now(),
+			UserID:    "user123",
+			Outcome:   models.AuditSuccess,
+			Details:   map[string]any{"attempt": i},
+		}, prev)
+		assert.NoError(t, err)
+		events = append(events, event)
+		prev = &events[len(events)-1]
+	}
+	return events
+}
+
+func hash(t *testing.T, event models.AuditEvent) string {
+	t.Helper()
+	h, err := auditchain.Hash(event)
+	assert.NoError(t, err)
+	return h
+}
+
+func verify(events []models.AuditEvent) (*auditchain.Verifier, error) {
+	var v auditchain.Verifier
+	for _, event := range events {
+		if err := v.Next(event); err != nil {
+			return &v, err
+		}
+	}
+	return &v, nil
+}
+
+func TestLink(t *testing.T) {
+	events := chain(t, 3)
+	assert.Equal(t, int64(1), events[0].Seq)
+	assert.Empty(t, events[0].PrevHash)
+	assert.Equal(t, events[0].Hash, events[1].PrevHash)
+	assert.Equal(t, int64(3), events[2].Seq)
+
+	v, err := verify(events)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), v.Checked())
+	assert.Equal(t, events[2].Hash, v.Head().Hash)
+}
+
+func TestVerifier_DetectsTampering(t *testing.T) {
+	events := chain(t, 3)
+	events[1].Outcome = models.AuditFailure
+	_, err := verify(events)
+	assert.ErrorIs(t, err, auditchain.ErrHashMismatch)
+
+	events = chain(t, 3)
+	events[1].UserID = "someone else"
+	events[1].Hash = hash(t, events[1])
+	v, err := verify(events)
+	assert.ErrorIs(t, err, auditchain.ErrPrevHashMismatch)
+	assert.Equal(t, int64(2), v.Checked())
+}
+
+func TestVerifier_DetectsRemovedEvent(t *testing.T) {
+	events := chain(t, 3)
+	_, err := verify(append(events[:1], events[2]))
+
+	var broken *auditchain.BrokenLinkError
+	assert.ErrorAs(t, err, &broken)
+	assert.Equal(t, int64(3), broken.Seq)
+	assert.ErrorIs(t, err, auditchain.ErrSequenceGap)
+}
+
+func TestHash_UnserializableDetails(t *testing.T) {
+	_, err := auditchain.Link(models.AuditEvent{ID: "a", Details: map[string]any{"callback": func() {}}}, nil)
+	assert.Error(t, err)
+
+	_, err = verify([]models.AuditEvent{{Seq: 1, ID: "a", Details: map[string]any{"value": make(chan int)}}})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, auditchain.ErrHashMismatch)
+}
+
+func TestVerifier_DetectsRemovedHead(t *testing.T) {
+	events := chain(t, 3)
+	head := events[2]
+
+	var v auditchain.Verifier
+	v.ExpectHead(head.Seq, head.Hash)
+	for _, event := range events[:2] {
+		assert.NoError(t, v.Next(event))
+	}
+	err := v.Finish()
+	var broken *auditchain.BrokenLinkError
+	assert.ErrorAs(t, err, &broken)
+	assert.Equal(t, int64(3), broken.Seq)
+	assert.ErrorIs(t, err, auditchain.ErrEventsRemoved)
+
+	// every event removed
+	v = auditchain.Verifier{}
+	v.ExpectHead(head.Seq, head.Hash)
+	assert.ErrorIs(t, v.Finish(), auditchain.ErrEventsRemoved)
+
+	// the head rewritten with a valid hash
+	v = auditchain.Verifier{}
+	v.ExpectHead(head.Seq, head.Hash)
+	rewritten := events[2]
+	rewritten.Outcome = models.AuditFailure
+	rewritten.Hash = hash(t, rewritten)
+	_, err = verify(append(events[:2:2], rewritten))
+	assert.NoError(t, err)
+	for _, event := range events[:2] {
+		assert.NoError(t, v.Next(event))
+	}
+	assert.ErrorIs(t, v.Next(rewritten), auditchain.ErrHeadMismatch)
+}
+
+func TestVerifier_EventsAppendedAfterHead(t *testing.T) {
+	events := chain(t, 3)
+
+	var v auditchain.Verifier
+	v.ExpectHead(events[1].Seq, events[1].Hash)
+	for _, event := range events {
+		assert.NoError(t, v.Next(event))
+	}
+	assert.NoError(t, v.Finish())
+	assert.NoError(t, (&auditchain.Verifier{}).Finish())
+}
@@ -0,0 +1,32 @@
+package auditchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrHashMismatch     = errors.New("event hash doesn't match its content")
+	ErrPrevHashMismatch = errors.New("previous hash doesn't match the previous event")
+	ErrSequenceGap      = errors.New("events are missing before this one")
+	ErrHeadMismatch     = errors.New("event doesn't match the chain head recorded by the writers")
+	ErrEventsRemoved    = errors.New("events are missing at the end of the chain")
+)
+
+// BrokenLinkError points to the first event of the chain that fails verification.
+type BrokenLinkError struct {
+	Seq     int64
+	EventID string
+	Err     error
+}
+
+func (e *BrokenLinkError) Error() string {
+	if e.EventID == "" {
+		return fmt.Sprintf("audit chain broken at seq %d: %s", e.Seq, e.Err)
+	}
+	return fmt.Sprintf("audit chain broken at seq %d (event %s): %s", e.Seq, e.EventID, e.Err)
+}
+
+func (e *BrokenLinkError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,102 @@
+package hasher
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"github.com/avran02/authentication/internal/config"
+	"golang.org/x/crypto/argon2"
+)
+
+// Limits of the parameters of stored hashes, which come from the database and from imports.
+// argon2.IDKey panics without iterations or parallelism and allocates the memory parameter as is.
+const (
+	maxArgon2Memory     = 1 << 21 // KiB, the 2 GiB of the first RFC 9106 recommendation
+	maxArgon2Iterations = 64
+)
+
+type argon2idAlgorithm struct {
+	config config.Argon2id
+}
+
+func (a *argon2idAlgorithm) hash(password string) (string, error) {
+	salt, err := randomSalt(a.config.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.config.Iterations, a.config.Memory, a.config.Parallelism, a.config.KeyLength)
+	return phcHash{
+		id:      Argon2id,
+		version: strconv.Itoa(argon2.Version),
+		params: map[string]string{
+			"m": strconv.FormatUint(uint64(a.config.Memory), 10),
+			"t": strconv.FormatUint(uint64(a.config.Iterations), 10),
+			"p": strconv.FormatUint(uint64(a.config.Parallelism), 10),
+		},
+		salt: salt,
+		hash: key,
+	}.String(), nil
+}
+
+func (a *argon2idAlgorithm) verify(password, encodedHash string) (bool, bool, error) {
+	h, params, err := parseArgon2id(encodedHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, params.iterations, params.memory, params.parallelism, uint32(len(h.hash)))
+	if subtle.ConstantTimeCompare(key, h.hash) != 1 {
+		return false, false, nil
+	}
+
+	outdated := params.memory != a.config.Memory ||
+		params.iterations != a.config.Iterations ||
+		params.parallelism != a.config.Parallelism ||
+		uint32(len(h.hash)) != a.config.KeyLength
+	return true, outdated, nil
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// parseArgon2id parses an argon2id PHC string and checks its parameters are within the limits.
+func parseArgon2id(encodedHash string) (phcHash, argon2idParams, error) {
+	h, err := parsePHC(encodedHash)
+	if err != nil {
+		return phcHash{}, argon2idParams{}, err
+	}
+	if h.version != strconv.Itoa(argon2.Version) {
+		return phcHash{}, argon2idParams{}, ErrMalformedHash
+	}
+
+	memory, err := h.uintParam("m", 32)
+	if err != nil {
+		return phcHash{}, argon2idParams{}, err
+	}
+	iterations, err := h.uintParam("t", 32)
+	if err != nil {
+		return phcHash{}, argon2idParams{}, err
+	}
+	parallelism, err := h.uintParam("p", 8)
+	if err != nil {
+		return phcHash{}, argon2idParams{}, err
+	}
+	switch {
+	case iterations < 1 || iterations > maxArgon2Iterations:
+		return phcHash{}, argon2idParams{}, fmt.Errorf("%w: t must be between 1 and %d", ErrMalformedHash, maxArgon2Iterations)
+	case parallelism < 1:
+		return phcHash{}, argon2idParams{}, fmt.Errorf("%w: p must be at least 1", ErrMalformedHash)
+	case memory < 8*parallelism || memory > maxArgon2Memory:
+		return phcHash{}, argon2idParams{}, fmt.Errorf("%w: m must be between 8*p and %d", ErrMalformedHash, maxArgon2Memory)
+	}
+	if err = checkKeyLength(h.hash); err != nil {
+		return phcHash{}, argon2idParams{}, err
+	}
+
+	return h, argon2idParams{memory: uint32(memory), iterations: uint32(iterations), parallelism: uint8(parallelism)}, nil
+}
@@ -0,0 +1,54 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/avran02/authentication/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxBcryptCost limits the cost of stored hashes, every step doubles the time to verify them.
+const maxBcryptCost = 16
+
+// maxBcryptPasswordLength is the longest password in bytes bcrypt.GenerateFromPassword accepts.
+const maxBcryptPasswordLength = 72
+
+type bcryptAlgorithm struct {
+	config config.Bcrypt
+}
+
+func (b *bcryptAlgorithm) hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), b.config.Cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate bcrypt hash: %w", err)
+	}
+	return string(hashed), nil
+}
+
+func (b *bcryptAlgorithm) verify(password, encodedHash string) (bool, bool, error) {
+	cost, err := bcryptCost(encodedHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	return true, cost != b.config.Cost, nil
+}
+
+func bcryptCost(encodedHash string) (int, error) {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	if cost > maxBcryptCost {
+		return 0, fmt.Errorf("%w: cost must be at most %d", ErrMalformedHash, maxBcryptCost)
+	}
+	return cost, nil
+}
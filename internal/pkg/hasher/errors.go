@@ -0,0 +1,8 @@
+package hasher
+
+import "errors"
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown hash algorithm")
+	ErrMalformedHash    = errors.New("malformed hash")
+)
@@ -0,0 +1,164 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/metrics"
+)
+
+const (
+	Argon2id = "argon2id"
+	Scrypt   = "scrypt"
+	Bcrypt   = "bcrypt"
+)
+
+// maxKeyLength limits the length of the derived keys in stored hashes.
+const maxKeyLength = 128
+
+// Hasher hashes passwords with the configured algorithm and verifies hashes made by any supported one.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encodedHash and whether the hash
+	// should be replaced because its algorithm or parameters are outdated.
+	Verify(password, encodedHash string) (ok, needsRehash bool, err error)
+	// MaxPasswordLength is the length in bytes of the longest password Hash accepts, 0 without a limit.
+	MaxPasswordLength() int
+	// Reload switches to the algorithm and parameters of config for new hashes.
+	// Hashes made with the old ones are rehashed on the next login.
+	Reload(config config.Hasher)
+}
+
+type algorithm interface {
+	hash(password string) (string, error)
+	verify(password, encodedHash string) (ok, outdated bool, err error)
+}
+
+type hasher struct {
+	// algorithms are swapped as a whole on Reload.
+	algorithms atomic.Pointer[algorithms]
+	legacy     map[string]verifier
+}
+
+type algorithms struct {
+	preferred string
+	byName    map[string]algorithm
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	algs := h.algorithms.Load()
+	defer metrics.Since(metrics.PasswordHashDuration.WithLabelValues(algs.preferred, "hash"), time.Now())
+	encoded, err := algs.byName[algs.preferred].hash(password)
+	if err != nil {
+		return "", fmt.Errorf("pkg.hasher.Hash: %w", err)
+	}
+	return encoded, nil
+}
+
+func (h *hasher) Verify(password, encodedHash string) (bool, bool, error) {
+	id := identify(encodedHash)
+	if legacy, ok := h.legacy[id]; ok {
+		defer metrics.Since(metrics.PasswordHashDuration.WithLabelValues(id, "verify"), time.Now())
+		ok, err := legacy.verify(password, encodedHash)
+		if err != nil {
+			return false, false, fmt.Errorf("pkg.hasher.Verify: %w", err)
+		}
+		return ok, ok, nil
+	}
+
+	algs := h.algorithms.Load()
+	alg, ok := algs.byName[id]
+	if !ok {
+		return false, false, fmt.Errorf("pkg.hasher.Verify: %w: %q", ErrUnknownAlgorithm, id)
+	}
+	defer metrics.Since(metrics.PasswordHashDuration.WithLabelValues(id, "verify"), time.Now())
+
+	ok, outdated, err := alg.verify(password, encodedHash)
+	if err != nil {
+		return false, false, fmt.Errorf("pkg.hasher.Verify: %w", err)
+	}
+	if !ok {
+		return false, false, nil
+	}
+
+	return true, outdated || id != algs.preferred, nil
+}
+
+// Validate checks that encodedHash can be verified, with parameters within the limits Verify accepts.
+// Use it for hashes that don't come from Hash, e.g. imported ones.
+func Validate(encodedHash string) error {
+	var err error
+	switch id := identify(encodedHash); id {
+	case Argon2id:
+		_, _, err = parseArgon2id(encodedHash)
+	case Scrypt:
+		_, _, err = parseScrypt(encodedHash)
+	case Bcrypt:
+		_, err = bcryptCost(encodedHash)
+	default:
+		legacy, ok := legacyVerifiers()[id]
+		if !ok {
+			return fmt.Errorf("pkg.hasher.Validate: %w: %q", ErrUnknownAlgorithm, id)
+		}
+		err = legacy.validate(encodedHash)
+	}
+	if err != nil {
+		return fmt.Errorf("pkg.hasher.Validate: %w", err)
+	}
+	return nil
+}
+
+// identify returns the algorithm name of a PHC string. Bcrypt hashes use their own modular crypt prefixes.
+func identify(encodedHash string) string {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encodedHash, prefix) {
+			return Bcrypt
+		}
+	}
+
+	id, _, _ := strings.Cut(strings.TrimPrefix(encodedHash, "$"), "$")
+	return id
+}
+
+func checkKeyLength(key []byte) error {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return fmt.Errorf("%w: hash must be between 1 and %d bytes", ErrMalformedHash, maxKeyLength)
+	}
+	return nil
+}
+
+func randomSalt(length uint32) ([]byte, error) {
+	salt := make([]byte, length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
+}
+
+func (h *hasher) MaxPasswordLength() int {
+	if h.algorithms.Load().preferred == Bcrypt {
+		return maxBcryptPasswordLength
+	}
+	return 0
+}
+
+func (h *hasher) Reload(config config.Hasher) {
+	h.algorithms.Store(&algorithms{
+		preferred: config.Algorithm,
+		byName: map[string]algorithm{
+			Argon2id: &argon2idAlgorithm{config: config.Argon2id},
+			Scrypt:   &scryptAlgorithm{config: config.Scrypt},
+			Bcrypt:   &bcryptAlgorithm{config: config.Bcrypt},
+		},
+	})
+}
+
+func New(config config.Hasher) Hasher {
+	h := &hasher{legacy: legacyVerifiers()}
+	h.Reload(config)
+	return h
+}
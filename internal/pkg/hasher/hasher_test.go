@@ -0,0 +1,170 @@
+package hasher_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	cfg = config.Hasher{
+		Algorithm: hasher.Argon2id,
+		Argon2id: config.Argon2id{
+			Memory:      1024,
+			Iterations:  1,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		Scrypt: config.Scrypt{
+			CostLog2:    10,
+			BlockSize:   8,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		Bcrypt: config.Bcrypt{
+			Cost: 4,
+		},
+	}
+	password = "correct horse battery staple"
+)
+
+func withAlgorithm(algorithm string) config.Hasher {
+	c := cfg
+	c.Algorithm = algorithm
+	return c
+}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	for _, algorithm := range []string{hasher.Argon2id, hasher.Scrypt, hasher.Bcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			h := hasher.New(withAlgorithm(algorithm))
+			encoded, err := h.Hash(password)
+			assert.NoError(t, err)
+
+			ok, needsRehash, err := h.Verify(password, encoded)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.False(t, needsRehash)
+
+			ok, _, err = h.Verify("wrong password", encoded)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestHasher_MaxPasswordLength(t *testing.T) {
+	h := hasher.New(withAlgorithm(hasher.Bcrypt))
+	assert.Equal(t, 72, h.MaxPasswordLength())
+	_, err := h.Hash(strings.Repeat("a", h.MaxPasswordLength()))
+	assert.NoError(t, err)
+	_, err = h.Hash(strings.Repeat("a", h.MaxPasswordLength()+1))
+	assert.Error(t, err)
+
+	h.Reload(cfg)
+	assert.Zero(t, h.MaxPasswordLength())
+}
+
+func TestHasher_Hash_PHCFormat(t *testing.T) {
+	encoded, err := hasher.New(cfg).Hash(password)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	encoded, err = hasher.New(withAlgorithm(hasher.Scrypt)).Hash(password)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$scrypt$ln=10,r=8,p=1$"))
+}
+
+func TestHasher_Verify_OutdatedAlgorithm(t *testing.T) {
+	encoded, err := hasher.New(withAlgorithm(hasher.Bcrypt)).Hash(password)
+	assert.NoError(t, err)
+
+	ok, needsRehash, err := hasher.New(cfg).Verify(password, encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+}
+
+func TestHasher_Verify_OutdatedParameters(t *testing.T) {
+	encoded, err := hasher.New(cfg).Hash(password)
+	assert.NoError(t, err)
+
+	stronger := cfg
+	stronger.Argon2id.Iterations = 2
+	ok, needsRehash, err := hasher.New(stronger).Verify(password, encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+}
+
+func TestHasher_Verify_UnknownAlgorithm(t *testing.T) {
+	_, _, err := hasher.New(cfg).Verify(password, "$md5$abc$def")
+	assert.ErrorIs(t, err, hasher.ErrUnknownAlgorithm)
+}
+
+func TestHasher_Verify_RejectsUnsafeParameters(t *testing.T) {
+	const saltAndHash = "$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g"
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=1024,t=0,p=1" + saltAndHash,
+		"$argon2id$v=19$m=1024,t=1,p=0" + saltAndHash,
+		"$argon2id$v=19$m=4,t=1,p=1" + saltAndHash,
+		"$argon2id$v=19$m=4294967295,t=1,p=1" + saltAndHash,
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+		"$scrypt$ln=0,r=8,p=1" + saltAndHash,
+		"$scrypt$ln=40,r=8,p=1" + saltAndHash,
+		"$scrypt$ln=20,r=4096,p=1" + saltAndHash,
+		"$scrypt$ln=10,r=8,p=0" + saltAndHash,
+	} {
+		_, _, err := hasher.New(cfg).Verify(password, encoded)
+		assert.ErrorIs(t, err, hasher.ErrMalformedHash, encoded)
+	}
+}
+
+func TestHasher_Verify_FirebaseScrypt(t *testing.T) {
+	// Test vector from https://github.com/firebase/scrypt
+	salt, _ := base64.StdEncoding.DecodeString("42xEC+ixf3L2lw==")
+	key, _ := base64.StdEncoding.DecodeString("lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==")
+	signerKey, _ := base64.StdEncoding.DecodeString("jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==")
+	encoded := hasher.FirebaseScryptHash(hasher.FirebaseScryptParams{
+		SignerKey:     signerKey,
+		SaltSeparator: []byte{0x07},
+		Rounds:        8,
+		MemCost:       14,
+	}, salt, key)
+
+	ok, needsRehash, err := hasher.New(cfg).Verify("user1password", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+}
+
+func TestHasher_Verify_LegacyAlgorithms(t *testing.T) {
+	salt := []byte("pepper")
+	sum := sha256.Sum256(append(append([]byte{}, salt...), password...))
+	pbkdf2Key := pbkdf2.Key([]byte(password), salt, 1000, 32, sha256.New)
+
+	for name, encoded := range map[string]string{
+		hasher.PBKDF2SHA256: hasher.PBKDF2SHA256Hash(1000, salt, pbkdf2Key),
+		hasher.SaltedSHA256: hasher.SaltedSHAHash(hasher.SaltedSHA256, hasher.SaltPrefix, salt, sum[:]),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ok, needsRehash, err := hasher.New(cfg).Verify(password, encoded)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, needsRehash)
+
+			ok, needsRehash, err = hasher.New(cfg).Verify("wrong password", encoded)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+			assert.False(t, needsRehash)
+		})
+	}
+}
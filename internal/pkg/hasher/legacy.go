@@ -0,0 +1,256 @@
+package hasher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1" //nolint:gosec // only used to verify imported legacy hashes
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Legacy algorithms are only verified; passwords hashed with them are rehashed on the first successful login.
+const (
+	PBKDF2SHA256   = "pbkdf2-sha256"
+	FirebaseScrypt = "firebase-scrypt"
+	SaltedSHA1     = "salted-sha1"
+	SaltedSHA256   = "salted-sha256"
+	SaltedSHA512   = "salted-sha512"
+
+	SaltPrefix = "prefix"
+	SaltSuffix = "suffix"
+)
+
+const (
+	firebaseScryptKeyLength = 64
+	// maxPBKDF2Iterations is ten times the 2023 OWASP recommendation for PBKDF2-HMAC-SHA256.
+	maxPBKDF2Iterations = 6_000_000
+)
+
+type verifier interface {
+	verify(password, encodedHash string) (bool, error)
+	// validate checks the hash can be verified, with parameters within the limits.
+	validate(encodedHash string) error
+}
+
+// FirebaseScryptParams are the project-wide hash parameters shown in the Firebase console.
+type FirebaseScryptParams struct {
+	SignerKey     []byte
+	SaltSeparator []byte
+	Rounds        int
+	MemCost       int
+}
+
+// PBKDF2SHA256Hash formats a PBKDF2-HMAC-SHA256 derived key as a PHC string.
+func PBKDF2SHA256Hash(iterations int, salt, key []byte) string {
+	return phcHash{
+		id:     PBKDF2SHA256,
+		params: map[string]string{"i": strconv.Itoa(iterations)},
+		salt:   salt,
+		hash:   key,
+	}.String()
+}
+
+// FirebaseScryptHash formats a password hash exported from Firebase Authentication as a PHC string.
+func FirebaseScryptHash(params FirebaseScryptParams, salt, key []byte) string {
+	return phcHash{
+		id: FirebaseScrypt,
+		params: map[string]string{
+			"ln": strconv.Itoa(params.MemCost),
+			"r":  strconv.Itoa(params.Rounds),
+			"sk": base64.RawStdEncoding.EncodeToString(params.SignerKey),
+			"ss": base64.RawStdEncoding.EncodeToString(params.SaltSeparator),
+		},
+		salt: salt,
+		hash: key,
+	}.String()
+}
+
+// SaltedSHAHash formats a single round salted SHA digest as a PHC string.
+// saltPosition tells whether the salt was prepended or appended to the password.
+func SaltedSHAHash(algorithm, saltPosition string, salt, sum []byte) string {
+	return phcHash{
+		id:     algorithm,
+		params: map[string]string{"pos": saltPosition},
+		salt:   salt,
+		hash:   sum,
+	}.String()
+}
+
+// ScryptHash formats a plain scrypt derived key as a PHC string.
+func ScryptHash(costLog2, blockSize, parallelism int, salt, key []byte) string {
+	return phcHash{
+		id: Scrypt,
+		params: map[string]string{
+			"ln": strconv.Itoa(costLog2),
+			"r":  strconv.Itoa(blockSize),
+			"p":  strconv.Itoa(parallelism),
+		},
+		salt: salt,
+		hash: key,
+	}.String()
+}
+
+type pbkdf2Verifier struct{}
+
+func (pbkdf2Verifier) verify(password, encodedHash string) (bool, error) {
+	h, iterations, err := parsePBKDF2(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	key := pbkdf2.Key([]byte(password), h.salt, iterations, len(h.hash), sha256.New)
+	return subtle.ConstantTimeCompare(key, h.hash) == 1, nil
+}
+
+func (pbkdf2Verifier) validate(encodedHash string) error {
+	_, _, err := parsePBKDF2(encodedHash)
+	return err
+}
+
+func parsePBKDF2(encodedHash string) (phcHash, int, error) {
+	h, err := parsePHC(encodedHash)
+	if err != nil {
+		return phcHash{}, 0, err
+	}
+	iterations, err := h.uintParam("i", 31)
+	if err != nil {
+		return phcHash{}, 0, err
+	}
+	if iterations < 1 || iterations > maxPBKDF2Iterations {
+		return phcHash{}, 0, fmt.Errorf("%w: i must be between 1 and %d", ErrMalformedHash, maxPBKDF2Iterations)
+	}
+	if err = checkKeyLength(h.hash); err != nil {
+		return phcHash{}, 0, err
+	}
+	return h, int(iterations), nil
+}
+
+type firebaseScryptVerifier struct{}
+
+func (firebaseScryptVerifier) verify(password, encodedHash string) (bool, error) {
+	h, err := parseFirebaseScrypt(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	salt := append(append([]byte{}, h.salt...), h.saltSeparator...)
+	derivedKey, err := scrypt.Key([]byte(password), salt, 1<<h.memCost, h.rounds, 1, firebaseScryptKeyLength)
+	if err != nil {
+		return false, fmt.Errorf("failed to derive scrypt key: %w", err)
+	}
+
+	block, err := aes.NewCipher(derivedKey[:32])
+	if err != nil {
+		return false, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	sum := make([]byte, len(h.signerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(sum, h.signerKey)
+
+	return subtle.ConstantTimeCompare(sum, h.hash) == 1, nil
+}
+
+func (firebaseScryptVerifier) validate(encodedHash string) error {
+	_, err := parseFirebaseScrypt(encodedHash)
+	return err
+}
+
+type firebaseScryptHash struct {
+	phcHash
+	memCost       uint64
+	rounds        int
+	signerKey     []byte
+	saltSeparator []byte
+}
+
+func parseFirebaseScrypt(encodedHash string) (firebaseScryptHash, error) {
+	h, err := parsePHC(encodedHash)
+	if err != nil {
+		return firebaseScryptHash{}, err
+	}
+	memCost, err := h.uintParam("ln", 6)
+	if err != nil {
+		return firebaseScryptHash{}, err
+	}
+	rounds, err := h.uintParam("r", 32)
+	if err != nil {
+		return firebaseScryptHash{}, err
+	}
+	if err = checkScryptParams(memCost, rounds, 1); err != nil {
+		return firebaseScryptHash{}, err
+	}
+	signerKey, err := base64.RawStdEncoding.DecodeString(h.params["sk"])
+	if err != nil || len(signerKey) == 0 || len(signerKey) > maxKeyLength {
+		return firebaseScryptHash{}, fmt.Errorf("%w: bad signer key", ErrMalformedHash)
+	}
+	saltSeparator, err := base64.RawStdEncoding.DecodeString(h.params["ss"])
+	if err != nil {
+		return firebaseScryptHash{}, fmt.Errorf("%w: bad salt separator", ErrMalformedHash)
+	}
+	if err = checkKeyLength(h.hash); err != nil {
+		return firebaseScryptHash{}, err
+	}
+	return firebaseScryptHash{
+		phcHash:       h,
+		memCost:       memCost,
+		rounds:        int(rounds),
+		signerKey:     signerKey,
+		saltSeparator: saltSeparator,
+	}, nil
+}
+
+type saltedSHAVerifier struct {
+	newHash func() hash.Hash
+}
+
+func (v saltedSHAVerifier) verify(password, encodedHash string) (bool, error) {
+	h, err := parsePHC(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	digest := v.newHash()
+	switch h.params["pos"] {
+	case SaltPrefix, "":
+		digest.Write(h.salt)
+		digest.Write([]byte(password))
+	case SaltSuffix:
+		digest.Write([]byte(password))
+		digest.Write(h.salt)
+	default:
+		return false, fmt.Errorf("%w: bad salt position", ErrMalformedHash)
+	}
+
+	return subtle.ConstantTimeCompare(digest.Sum(nil), h.hash) == 1, nil
+}
+
+func (v saltedSHAVerifier) validate(encodedHash string) error {
+	h, err := parsePHC(encodedHash)
+	if err != nil {
+		return err
+	}
+	if pos := h.params["pos"]; pos != SaltPrefix && pos != SaltSuffix && pos != "" {
+		return fmt.Errorf("%w: bad salt position", ErrMalformedHash)
+	}
+	if len(h.hash) != v.newHash().Size() {
+		return fmt.Errorf("%w: hash must be %d bytes", ErrMalformedHash, v.newHash().Size())
+	}
+	return nil
+}
+
+func legacyVerifiers() map[string]verifier {
+	return map[string]verifier{
+		PBKDF2SHA256:   pbkdf2Verifier{},
+		FirebaseScrypt: firebaseScryptVerifier{},
+		SaltedSHA1:     saltedSHAVerifier{newHash: sha1.New},
+		SaltedSHA256:   saltedSHAVerifier{newHash: sha256.New},
+		SaltedSHA512:   saltedSHAVerifier{newHash: sha512.New},
+	}
+}
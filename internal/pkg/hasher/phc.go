@@ -0,0 +1,110 @@
+package hasher
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// phcHash is a parsed PHC string: $<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]].
+type phcHash struct {
+	id      string
+	version string
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+func parsePHC(encoded string) (phcHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 2 || parts[0] != "" || parts[1] == "" {
+		return phcHash{}, ErrMalformedHash
+	}
+
+	h := phcHash{id: parts[1], params: map[string]string{}}
+	rest := parts[2:]
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "v=") {
+		h.version = strings.TrimPrefix(rest[0], "v=")
+		rest = rest[1:]
+	}
+
+	if len(rest) > 0 && strings.Contains(rest[0], "=") {
+		for _, kv := range strings.Split(rest[0], ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return phcHash{}, ErrMalformedHash
+			}
+			h.params[k] = v
+		}
+		rest = rest[1:]
+	}
+
+	var err error
+	if len(rest) > 0 {
+		if h.salt, err = base64.RawStdEncoding.DecodeString(rest[0]); err != nil {
+			return phcHash{}, fmt.Errorf("%w: bad salt encoding", ErrMalformedHash)
+		}
+	}
+	if len(rest) > 1 {
+		if h.hash, err = base64.RawStdEncoding.DecodeString(rest[1]); err != nil {
+			return phcHash{}, fmt.Errorf("%w: bad hash encoding", ErrMalformedHash)
+		}
+	}
+	if len(rest) > 2 { //nolint:mnd
+		return phcHash{}, ErrMalformedHash
+	}
+
+	return h, nil
+}
+
+func (h phcHash) String() string {
+	var b strings.Builder
+	b.WriteString("$" + h.id)
+	if h.version != "" {
+		b.WriteString("$v=" + h.version)
+	}
+	if len(h.params) > 0 {
+		b.WriteString("$" + h.paramString())
+	}
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(h.salt))
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(h.hash))
+	return b.String()
+}
+
+// paramString keeps the conventional order of the parameters of every supported algorithm.
+func (h phcHash) paramString() string {
+	order := []string{"m", "t", "ln", "r", "p"}
+	params := make([]string, 0, len(h.params))
+	seen := make(map[string]bool, len(h.params))
+	for _, k := range order {
+		if v, ok := h.params[k]; ok {
+			params = append(params, k+"="+v)
+			seen[k] = true
+		}
+	}
+	extra := make([]string, 0, len(h.params))
+	for k := range h.params {
+		if !seen[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		params = append(params, k+"="+h.params[k])
+	}
+	return strings.Join(params, ",")
+}
+
+func (h phcHash) uintParam(name string, bitSize int) (uint64, error) {
+	v, ok := h.params[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: missing parameter %q", ErrMalformedHash, name)
+	}
+	n, err := strconv.ParseUint(v, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad parameter %q", ErrMalformedHash, name)
+	}
+	return n, nil
+}
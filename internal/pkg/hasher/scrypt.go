@@ -0,0 +1,112 @@
+package hasher
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"github.com/avran02/authentication/internal/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Limits of the parameters of stored hashes, scrypt needs 128 * r * 2^ln bytes of memory.
+const (
+	maxScryptCostLog2    = 24
+	maxScryptMemory      = 1 << 31 // bytes
+	maxScryptParallelism = 64
+)
+
+type scryptAlgorithm struct {
+	config config.Scrypt
+}
+
+func (s *scryptAlgorithm) hash(password string) (string, error) {
+	salt, err := randomSalt(s.config.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<s.config.CostLog2, s.config.BlockSize, s.config.Parallelism, int(s.config.KeyLength))
+	if err != nil {
+		return "", fmt.Errorf("failed to derive scrypt key: %w", err)
+	}
+
+	return phcHash{
+		id: Scrypt,
+		params: map[string]string{
+			"ln": strconv.Itoa(int(s.config.CostLog2)),
+			"r":  strconv.Itoa(s.config.BlockSize),
+			"p":  strconv.Itoa(s.config.Parallelism),
+		},
+		salt: salt,
+		hash: key,
+	}.String(), nil
+}
+
+func (s *scryptAlgorithm) verify(password, encodedHash string) (bool, bool, error) {
+	h, params, err := parseScrypt(encodedHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	key, err := scrypt.Key([]byte(password), h.salt, 1<<params.costLog2, params.blockSize, params.parallelism, len(h.hash))
+	if err != nil {
+		return false, false, fmt.Errorf("failed to derive scrypt key: %w", err)
+	}
+	if subtle.ConstantTimeCompare(key, h.hash) != 1 {
+		return false, false, nil
+	}
+
+	outdated := params.costLog2 != s.config.CostLog2 ||
+		params.blockSize != s.config.BlockSize ||
+		params.parallelism != s.config.Parallelism ||
+		uint32(len(h.hash)) != s.config.KeyLength
+	return true, outdated, nil
+}
+
+type scryptParams struct {
+	costLog2    uint8
+	blockSize   int
+	parallelism int
+}
+
+// parseScrypt parses a scrypt PHC string and checks its parameters are within the limits.
+func parseScrypt(encodedHash string) (phcHash, scryptParams, error) {
+	h, err := parsePHC(encodedHash)
+	if err != nil {
+		return phcHash{}, scryptParams{}, err
+	}
+
+	costLog2, err := h.uintParam("ln", 6)
+	if err != nil {
+		return phcHash{}, scryptParams{}, err
+	}
+	blockSize, err := h.uintParam("r", 32)
+	if err != nil {
+		return phcHash{}, scryptParams{}, err
+	}
+	parallelism, err := h.uintParam("p", 32)
+	if err != nil {
+		return phcHash{}, scryptParams{}, err
+	}
+	if err = checkScryptParams(costLog2, blockSize, parallelism); err != nil {
+		return phcHash{}, scryptParams{}, err
+	}
+	if err = checkKeyLength(h.hash); err != nil {
+		return phcHash{}, scryptParams{}, err
+	}
+
+	return h, scryptParams{costLog2: uint8(costLog2), blockSize: int(blockSize), parallelism: int(parallelism)}, nil
+}
+
+func checkScryptParams(costLog2, blockSize, parallelism uint64) error {
+	switch {
+	case costLog2 < 1 || costLog2 > maxScryptCostLog2:
+		return fmt.Errorf("%w: ln must be between 1 and %d", ErrMalformedHash, maxScryptCostLog2)
+	case blockSize < 1 || 128*blockSize<<costLog2 > maxScryptMemory:
+		return fmt.Errorf("%w: r must be at least 1 and 128*r*2^ln at most %d", ErrMalformedHash, maxScryptMemory)
+	case parallelism < 1 || parallelism > maxScryptParallelism:
+		return fmt.Errorf("%w: p must be between 1 and %d", ErrMalformedHash, maxScryptParallelism)
+	}
+	return nil
+}
@@ -6,4 +6,7 @@ var (
 	ErrEmptyToken   = errors.New("token is empty")
 	ErrInvalidToken = errors.New("token is invalid")
 	ErrExpiredToken = errors.New("token is expired")
+
+	ErrMissingSigningKey = errors.New("signing key is not configured")
+	ErrInvalidKeySet     = errors.New("invalid key set")
 )
@@ -1,8 +1,10 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/avran02/authentication/internal/config"
@@ -12,27 +14,41 @@ import (
 )
 
 type Generator interface {
-	Generate(userID string) (accessToken, accessTokenID, refreshToken string, expTime time.Time, err error)
+	Generate(userID string, roles []string) (accessToken, accessTokenID, refreshToken string, expTime time.Time, err error)
 	ParseAccessToken(token string) (models.AccessTokenClaims, error)
 	ParseRefreshToken(token string) (models.RefreshTokenClaims, error)
+	// CheckKeys verifies that tokens can be signed and verified with the configured key.
+	CheckKeys() error
+	// Reload switches to the key set and token lifetimes of config. Tokens being signed
+	// or parsed meanwhile use either the old or the new settings.
+	Reload(config config.JWT) error
 }
 
 type jwtGenerator struct {
+	state atomic.Pointer[generatorState]
+}
+
+type generatorState struct {
 	config config.JWT
+	keys   KeySet
 }
 
-func (j *jwtGenerator) Generate(userID string) (accessToken, accessTokenID, refreshToken string, refreshExp time.Time, err error) {
+func (j *jwtGenerator) Generate(userID string, roles []string) (accessToken, accessTokenID, refreshToken string, refreshExp time.Time, err error) {
 	slog.Info("pkg.jwt.Generate")
-	accessClaims := j.newAccessClaims(userID)
-	unsignedAccessToken := jwt.NewWithClaims(jwt.SigningMethodHS512, accessClaims)
-	accessToken, err = unsignedAccessToken.SignedString([]byte(j.config.Secret))
+	state := j.state.Load()
+	key, ok := state.keys.signingKey()
+	if !ok {
+		return "", "", "", time.Time{}, fmt.Errorf("pkg.jwt.Generate: %w", ErrMissingSigningKey)
+	}
+
+	accessClaims := newAccessClaims(state.config, userID, roles)
+	accessToken, err = sign(key, accessClaims)
 	if err != nil {
 		return "", "", "", time.Time{}, fmt.Errorf("pkg.jwt.Generate: failed to sign token: %w", err)
 	}
 
-	expTime, refreshClaims := j.newRefreshClaims(userID, accessClaims.ID)
-	unsignedRefreshToken := jwt.NewWithClaims(jwt.SigningMethodHS512, refreshClaims)
-	refreshToken, err = unsignedRefreshToken.SignedString([]byte(j.config.Secret))
+	expTime, refreshClaims := newRefreshClaims(state.config, userID, accessClaims.ID)
+	refreshToken, err = sign(key, refreshClaims)
 	if err != nil {
 		return "", "", "", time.Time{}, fmt.Errorf("pkg.jwt.Generate: failed to sign token: %w", err)
 	}
@@ -50,10 +66,10 @@ func (j *jwtGenerator) ParseAccessToken(token string) (models.AccessTokenClaims,
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(j.config.Secret), nil
+		return j.state.Load().keys.verificationKey(t.Header["kid"])
 	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}))
 	if err != nil {
-		return models.AccessTokenClaims{}, fmt.Errorf("pkg.jwt.ValidateAccessToken: failed to parse token: %w", err)
+		return models.AccessTokenClaims{}, fmt.Errorf("pkg.jwt.ValidateAccessToken: failed to parse token: %w", parseError(err))
 	}
 
 	if !parsedToken.Valid {
@@ -83,10 +99,10 @@ func (j *jwtGenerator) ParseRefreshToken(token string) (models.RefreshTokenClaim
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(j.config.Secret), nil
+		return j.state.Load().keys.verificationKey(t.Header["kid"])
 	})
 	if err != nil {
-		return models.RefreshTokenClaims{}, fmt.Errorf("pkg.jwt.ParseRefreshToken: failed to parse token: %w", err)
+		return models.RefreshTokenClaims{}, fmt.Errorf("pkg.jwt.ParseRefreshToken: failed to parse token: %w", parseError(err))
 	}
 
 	if !parsedToken.Valid {
@@ -107,11 +123,19 @@ func (j *jwtGenerator) ParseRefreshToken(token string) (models.RefreshTokenClaim
 	return *claims, nil
 }
 
-func (j *jwtGenerator) newAccessClaims(userID string) models.AccessTokenClaims {
-	tokenLifetime := time.Duration(j.config.AccessExp) * time.Second
+// sign signs claims with key and names the key in the kid header.
+func sign(key Key, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString([]byte(key.Secret))
+}
+
+func newAccessClaims(config config.JWT, userID string, roles []string) models.AccessTokenClaims {
+	tokenLifetime := time.Duration(config.AccessExp) * time.Second
 	accessTokenExpiresAt := jwt.NewNumericDate(time.Now().Add(tokenLifetime))
 
 	return models.AccessTokenClaims{
+		Roles: roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: accessTokenExpiresAt,
 			Subject:   userID,
@@ -120,8 +144,8 @@ func (j *jwtGenerator) newAccessClaims(userID string) models.AccessTokenClaims {
 	}
 }
 
-func (j *jwtGenerator) newRefreshClaims(userID, accessTokenID string) (time.Time, models.RefreshTokenClaims) {
-	tokenLifetime := time.Duration(j.config.RefreshExp) * time.Second
+func newRefreshClaims(config config.JWT, userID, accessTokenID string) (time.Time, models.RefreshTokenClaims) {
+	tokenLifetime := time.Duration(config.RefreshExp) * time.Second
 	expTime := time.Now().Add(tokenLifetime)
 	refreshTokenExpiresAt := jwt.NewNumericDate(expTime)
 
@@ -135,8 +159,48 @@ func (j *jwtGenerator) newRefreshClaims(userID, accessTokenID string) (time.Time
 	}
 }
 
-func NewJwtGenerator(config config.JWT) Generator {
-	return &jwtGenerator{
-		config: config,
+func (j *jwtGenerator) CheckKeys() error {
+	keys := j.state.Load().keys
+	key, ok := keys.signingKey()
+	if !ok {
+		return ErrMissingSigningKey
+	}
+
+	token, err := sign(key, jwt.RegisteredClaims{Subject: "key-check"})
+	if err != nil {
+		return fmt.Errorf("pkg.jwt.CheckKeys: failed to sign token: %w", err)
+	}
+	_, err = jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		return keys.verificationKey(t.Header["kid"])
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}))
+	if err != nil {
+		return fmt.Errorf("pkg.jwt.CheckKeys: failed to verify token: %w", err)
+	}
+	return nil
+}
+
+func (j *jwtGenerator) Reload(config config.JWT) error {
+	keys, err := LoadKeys(config)
+	if err != nil {
+		return err
+	}
+	j.state.Store(&generatorState{config: config, keys: keys})
+	return nil
+}
+
+// NewJwtGenerator fails if the key set can't be loaded. Without any key it only fails to sign tokens.
+func NewJwtGenerator(config config.JWT) (Generator, error) {
+	j := &jwtGenerator{}
+	if err := j.Reload(config); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// parseError tells expired tokens apart from otherwise invalid ones.
+func parseError(err error) error {
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return ErrExpiredToken
 	}
+	return fmt.Errorf("%w: %w", ErrInvalidToken, err)
 }
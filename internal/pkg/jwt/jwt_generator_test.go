@@ -1,6 +1,9 @@
 package jwt_test
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 	jwtGenerator "github.com/avran02/authentication/internal/pkg/jwt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -17,12 +21,20 @@ var (
 		AccessExp:  3600,
 		RefreshExp: 86400,
 	}
-	gen    = jwtGenerator.NewJwtGenerator(cfg)
+	gen    = mustGenerator(cfg)
 	userID = "user123"
 )
 
+func mustGenerator(cfg config.JWT) jwtGenerator.Generator {
+	gen, err := jwtGenerator.NewJwtGenerator(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return gen
+}
+
 func TestJwtGenerator_Generate(t *testing.T) {
-	accessToken, accessTokenID, refreshToken, _, err := gen.Generate(userID)
+	accessToken, accessTokenID, refreshToken, _, err := gen.Generate(userID, nil)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, accessToken)
 	assert.NotEmpty(t, refreshToken)
@@ -30,7 +42,7 @@ func TestJwtGenerator_Generate(t *testing.T) {
 }
 
 func TestJwtGenerator_ParseAccessToken(t *testing.T) {
-	accessToken, _, _, _, err := gen.Generate(userID)
+	accessToken, _, _, _, err := gen.Generate(userID, nil)
 	assert.NoError(t, err)
 
 	claims, err := gen.ParseAccessToken(accessToken)
@@ -38,6 +50,15 @@ func TestJwtGenerator_ParseAccessToken(t *testing.T) {
 	assert.Equal(t, userID, claims.Subject)
 }
 
+func TestJwtGenerator_ParseAccessToken_Roles(t *testing.T) {
+	accessToken, _, _, _, err := gen.Generate(userID, []string{models.RoleAdmin})
+	assert.NoError(t, err)
+
+	claims, err := gen.ParseAccessToken(accessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.RoleAdmin}, claims.Roles)
+}
+
 func TestJwtGenerator_ParseAccessToken_ExpiredToken(t *testing.T) {
 	claims := models.AccessTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -51,11 +72,23 @@ func TestJwtGenerator_ParseAccessToken_ExpiredToken(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = gen.ParseAccessToken(signedToken)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, jwtGenerator.ErrExpiredToken)
+}
+
+func TestJwtGenerator_ParseAccessToken_InvalidToken(t *testing.T) {
+	accessToken, _, _, _, err := gen.Generate(userID, nil)
+	assert.NoError(t, err)
+
+	otherGen := mustGenerator(config.JWT{Secret: "othersecret", AccessExp: 3600, RefreshExp: 86400})
+	_, err = otherGen.ParseAccessToken(accessToken)
+	assert.ErrorIs(t, err, jwtGenerator.ErrInvalidToken)
+
+	_, err = gen.ParseAccessToken("not.a.token")
+	assert.ErrorIs(t, err, jwtGenerator.ErrInvalidToken)
 }
 
 func TestJwtGenerator_ParseRefreshToken(t *testing.T) {
-	_, accessTokenID, refreshToken, _, err := gen.Generate(userID)
+	_, accessTokenID, refreshToken, _, err := gen.Generate(userID, nil)
 	assert.NoError(t, err)
 
 	claims, err := gen.ParseRefreshToken(refreshToken)
@@ -77,5 +110,78 @@ func TestJwtGenerator_ParseRefreshToken_ExpiredToken(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = gen.ParseRefreshToken(signedToken)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, jwtGenerator.ErrExpiredToken)
+}
+
+func TestJwtGenerator_CheckKeys(t *testing.T) {
+	assert.NoError(t, gen.CheckKeys())
+
+	noKey := mustGenerator(config.JWT{AccessExp: 3600, RefreshExp: 86400})
+	assert.ErrorIs(t, noKey.CheckKeys(), jwtGenerator.ErrMissingSigningKey)
+}
+
+func writeKeys(t *testing.T, keys jwtGenerator.KeySet) string {
+	t.Helper()
+	data, err := yaml.Marshal(keys)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "keys.yml")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestJwtGenerator_ReloadRotatesKeys(t *testing.T) {
+	oldKey := jwtGenerator.Key{ID: "old", Secret: strings.Repeat("o", 64)}
+	newKey := jwtGenerator.Key{ID: "new", Secret: strings.Repeat("n", 64)}
+	rotating := config.JWT{AccessExp: 3600, RefreshExp: 86400}
+
+	rotating.KeysFile = writeKeys(t, jwtGenerator.KeySet{Active: "old", Keys: []jwtGenerator.Key{oldKey}})
+	g := mustGenerator(rotating)
+	oldToken, _, _, _, err := g.Generate(userID, nil)
+	assert.NoError(t, err)
+
+	rotating.KeysFile = writeKeys(t, jwtGenerator.KeySet{Active: "new", Keys: []jwtGenerator.Key{newKey, oldKey}})
+	assert.NoError(t, g.Reload(rotating))
+	newToken, _, _, _, err := g.Generate(userID, nil)
+	assert.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &models.AccessTokenClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	for _, token := range []string{oldToken, newToken} {
+		_, err = g.ParseAccessToken(token)
+		assert.NoError(t, err)
+	}
+
+	rotating.KeysFile = writeKeys(t, jwtGenerator.KeySet{Active: "new", Keys: []jwtGenerator.Key{newKey}})
+	assert.NoError(t, g.Reload(rotating))
+	_, err = g.ParseAccessToken(oldToken)
+	assert.ErrorIs(t, err, jwtGenerator.ErrInvalidToken)
+}
+
+func TestJwtGenerator_ReloadKeepsKeysOnError(t *testing.T) {
+	g := mustGenerator(cfg)
+	token, _, _, _, err := g.Generate(userID, nil)
+	assert.NoError(t, err)
+
+	weak := writeKeys(t, jwtGenerator.KeySet{Active: "weak", Keys: []jwtGenerator.Key{{ID: "weak", Secret: "short"}}})
+	err = g.Reload(config.JWT{KeysFile: weak, AccessExp: 3600, RefreshExp: 86400})
+	assert.ErrorIs(t, err, jwtGenerator.ErrInvalidKeySet)
+
+	_, err = g.ParseAccessToken(token)
+	assert.NoError(t, err)
+}
+
+func TestJwtGenerator_ParseTokenWithoutKeyID(t *testing.T) {
+	claims := models.AccessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			Subject:   userID,
+		},
+	}
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(cfg.Secret))
+	assert.NoError(t, err)
+
+	parsed, err := gen.ParseAccessToken(signedToken)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, parsed.Subject)
 }
@@ -0,0 +1,113 @@
+package jwt
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultKeyID identifies the key given with jwt.secret.
+const defaultKeyID = "default"
+
+// Key is an HMAC key. Its ID is sent in the kid header of the tokens it signed.
+type Key struct {
+	ID        string    `yaml:"id"`
+	Secret    string    `yaml:"secret"`
+	CreatedAt time.Time `yaml:"created_at,omitempty"`
+}
+
+// KeySet is the content of the jwt.keys_file. New tokens are signed with the Active key,
+// the other keys only verify tokens signed before a rotation.
+type KeySet struct {
+	Active string `yaml:"active"`
+	Keys   []Key  `yaml:"keys"`
+}
+
+// LoadKeys reads the key set from conf.KeysFile, or makes one of conf.Secret if no file is set.
+// An empty set is returned if neither is configured.
+func LoadKeys(conf config.JWT) (KeySet, error) {
+	if conf.KeysFile == "" {
+		if conf.Secret == "" {
+			return KeySet{}, nil
+		}
+		return KeySet{Active: defaultKeyID, Keys: []Key{{ID: defaultKeyID, Secret: conf.Secret}}}, nil
+	}
+
+	data, err := os.ReadFile(conf.KeysFile)
+	if err != nil {
+		return KeySet{}, fmt.Errorf("pkg.jwt.LoadKeys: %w", err)
+	}
+	var keys KeySet
+	if err = yaml.Unmarshal(data, &keys); err != nil {
+		return KeySet{}, fmt.Errorf("pkg.jwt.LoadKeys: can't decode %s: %w", conf.KeysFile, err)
+	}
+	if err = keys.Validate(); err != nil {
+		return KeySet{}, fmt.Errorf("pkg.jwt.LoadKeys: %s: %w", conf.KeysFile, err)
+	}
+	return keys, nil
+}
+
+// Validate checks that the IDs are unique, the secrets are strong enough and the active key exists.
+func (s KeySet) Validate() error {
+	if len(s.Keys) == 0 {
+		return ErrMissingSigningKey
+	}
+	seen := make(map[string]bool, len(s.Keys))
+	for _, key := range s.Keys {
+		if key.ID == "" || seen[key.ID] {
+			return fmt.Errorf("%w: key ids must be set and unique, got %q", ErrInvalidKeySet, key.ID)
+		}
+		seen[key.ID] = true
+		if len(key.Secret) < config.MinJWTSecretLength {
+			return fmt.Errorf("%w: secret of key %q is shorter than %d bytes", ErrInvalidKeySet, key.ID, config.MinJWTSecretLength)
+		}
+	}
+	if !seen[s.Active] {
+		return fmt.Errorf("%w: active key %q is not in the set", ErrInvalidKeySet, s.Active)
+	}
+	return nil
+}
+
+// Save writes the key set to path, readable by the owner only.
+func (s KeySet) Save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("pkg.jwt.Save: %w", err)
+	}
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("pkg.jwt.Save: %w", err)
+	}
+	return nil
+}
+
+func (s KeySet) signingKey() (Key, bool) {
+	i := slices.IndexFunc(s.Keys, func(k Key) bool { return k.ID == s.Active })
+	if i < 0 {
+		return Key{}, false
+	}
+	return s.Keys[i], true
+}
+
+// verificationKey returns the key with the kid of the token. Tokens issued before key IDs
+// were introduced have none and are checked against every key.
+func (s KeySet) verificationKey(kid any) (interface{}, error) {
+	id, ok := kid.(string)
+	if !ok {
+		set := jwt.VerificationKeySet{}
+		for _, key := range s.Keys {
+			set.Keys = append(set.Keys, []byte(key.Secret))
+		}
+		return set, nil
+	}
+
+	i := slices.IndexFunc(s.Keys, func(k Key) bool { return k.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, id)
+	}
+	return []byte(s.Keys[i].Secret), nil
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows a number of events per key in fixed time windows. It is safe for concurrent use.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int
+	period  time.Duration
+	windows map[string]*window
+	pruned  time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// New returns a Limiter allowing limit events per key every period. A limit of 0 allows everything.
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{limit: limit, period: period, windows: map[string]*window{}}
+}
+
+// Reload applies limit and period to the next events. The counts of the current windows are kept.
+func (l *Limiter) Reload(limit int, period time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit, l.period = limit, period
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+func (l *Limiter) Allow(key string) bool {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.prune(now)
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.period {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	w.count++
+	return w.count <= l.limit
+}
+
+// prune drops the windows that are over, at most once per period, so the keys of past
+// clients don't pile up.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < l.period {
+		return
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.period {
+			delete(l.windows, key)
+		}
+	}
+	l.pruned = now
+}
@@ -0,0 +1,44 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avran02/authentication/internal/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowLimitsEachKey(t *testing.T) {
+	l := ratelimit.New(2, time.Minute)
+
+	assert.True(t, l.Allow("10.0.0.1"))
+	assert.True(t, l.Allow("10.0.0.1"))
+	assert.False(t, l.Allow("10.0.0.1"))
+	assert.True(t, l.Allow("10.0.0.2"))
+}
+
+func TestAllowStartsANewWindow(t *testing.T) {
+	l := ratelimit.New(1, 20*time.Millisecond)
+
+	assert.True(t, l.Allow("10.0.0.1"))
+	assert.False(t, l.Allow("10.0.0.1"))
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, l.Allow("10.0.0.1"))
+}
+
+func TestAllowWithoutLimit(t *testing.T) {
+	l := ratelimit.New(0, time.Minute)
+
+	for range 100 {
+		assert.True(t, l.Allow("10.0.0.1"))
+	}
+}
+
+func TestReloadKeepsTheCounts(t *testing.T) {
+	l := ratelimit.New(3, time.Minute)
+
+	assert.True(t, l.Allow("10.0.0.1"))
+	assert.True(t, l.Allow("10.0.0.1"))
+	l.Reload(2, time.Minute)
+	assert.False(t, l.Allow("10.0.0.1"))
+}
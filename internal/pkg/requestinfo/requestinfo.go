@@ -0,0 +1,29 @@
+package requestinfo
+
+import "context"
+
+// Info describes who sent a request. It is filled by the HTTP middleware and the gRPC interceptor.
+type Info struct {
+	IP        string
+	UserAgent string
+	// ActorID is the authenticated user, if any.
+	ActorID string
+}
+
+type contextKey struct{}
+
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// WithActor keeps the IP and user agent already stored in ctx.
+func WithActor(ctx context.Context, actorID string) context.Context {
+	info := FromContext(ctx)
+	info.ActorID = actorID
+	return WithInfo(ctx, info)
+}
+
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrUserNotFound  = errors.New("user does not exists")
 	ErrTokenNotFound = errors.New("token doesn't exist")
+	ErrDuplicateUser = errors.New("user with the same username or email exists")
+
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint doesn't exist")
 )
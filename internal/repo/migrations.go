@@ -0,0 +1,239 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type migration struct {
+	version     int
+	description string
+	up, down    func(ctx context.Context, r *repo) error
+}
+
+// migrations are applied in order. Add new ones at the end and never change applied ones.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "users: case-insensitive unique email index",
+		up: func(ctx context.Context, r *repo) error {
+			_, err := r.userCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().
+					SetName("email_ci_unique").
+					SetUnique(true).
+					SetCollation(emailCollation).
+					SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
+			})
+			return err
+		},
+		down: dropIndexes(func(r *repo) *mongo.Collection { return r.userCollection }, "email_ci_unique"),
+	},
+	{
+		version:     2,
+		description: "audit: unique sequence and lookup indexes",
+		up: func(ctx context.Context, r *repo) error {
+			_, err := r.auditCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "seq", Value: 1}},
+					Options: options.Index().
+						SetName("seq_unique").
+						SetUnique(true).
+						SetPartialFilterExpression(chainedAuditEvents),
+				},
+				{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+				{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "timestamp", Value: -1}}},
+			})
+			return err
+		},
+		down: dropIndexes(func(r *repo) *mongo.Collection { return r.auditCollection },
+			"seq_unique", "timestamp_-1", "userid_1_timestamp_-1"),
+	},
+	{
+		version:     3,
+		description: "outbox: unique id, pending events and TTL indexes",
+		up: func(ctx context.Context, r *repo) error {
+			_, err := r.outboxCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "publishedat", Value: 1}, {Key: "nextattemptat", Value: 1}, {Key: "createdat", Value: 1}}},
+				{
+					Keys:    bson.D{{Key: "publishedat", Value: 1}},
+					Options: options.Index().SetName("published_ttl").SetExpireAfterSeconds(int32(publishedOutboxRetention.Seconds())),
+				},
+			})
+			return err
+		},
+		down: dropIndexes(func(r *repo) *mongo.Collection { return r.outboxCollection },
+			"id_1", "publishedat_1_nextattemptat_1_createdat_1", "published_ttl"),
+	},
+	{
+		version:     4,
+		description: "revocations: unique sequence and TTL indexes",
+		up: func(ctx context.Context, r *repo) error {
+			_, err := r.revocationsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{
+					Keys:    bson.D{{Key: "revokedat", Value: 1}},
+					Options: options.Index().SetName("revoked_ttl").SetExpireAfterSeconds(int32(revocationRetention.Seconds())),
+				},
+			})
+			return err
+		},
+		down: dropIndexes(func(r *repo) *mongo.Collection { return r.revocationsCollection }, "seq_1", "revoked_ttl"),
+	},
+	{
+		version:     5,
+		description: "counters: unique id index, audit chain head counter",
+		up: func(ctx context.Context, r *repo) error {
+			// concurrent upserts of a new counter must not create two of them
+			_, err := r.countersCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true),
+			})
+			if err != nil {
+				return err
+			}
+
+			var head models.AuditEvent
+			opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+			err = r.auditCollection.FindOne(ctx, chainedAuditEvents, opts).Decode(&head)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			_, err = r.countersCollection.UpdateOne(ctx,
+				bson.M{"id": auditCounter},
+				bson.M{"$setOnInsert": bson.M{"seq": head.Seq, "hash": head.Hash}},
+				options.Update().SetUpsert(true),
+			)
+			return err
+		},
+		down: func(ctx context.Context, r *repo) error {
+			if _, err := r.countersCollection.DeleteOne(ctx, bson.M{"id": auditCounter}); err != nil {
+				return err
+			}
+			return dropIndexes(func(r *repo) *mongo.Collection { return r.countersCollection }, "id_1")(ctx, r)
+		},
+	},
+	{
+		version:     6,
+		description: "users: unique username and id indexes",
+		up: func(ctx context.Context, r *repo) error {
+			// usernames are checked before users are written, the index keeps concurrent requests apart
+			_, err := r.userCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			})
+			return err
+		},
+		down: dropIndexes(func(r *repo) *mongo.Collection { return r.userCollection }, "username_1", "id_1"),
+	},
+}
+
+// dropIndexes returns a migration step dropping the named indexes. Missing indexes are skipped.
+func dropIndexes(collection func(r *repo) *mongo.Collection, names ...string) func(ctx context.Context, r *repo) error {
+	return func(ctx context.Context, r *repo) error {
+		for _, name := range names {
+			_, err := collection(r).Indexes().DropOne(ctx, name)
+			var cmdErr mongo.CommandError
+			if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to drop index %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedat"`
+}
+
+func (r *repo) MigrationStatus(ctx context.Context) ([]models.Migration, error) {
+	cursor, err := r.migrationsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find applied migrations: %w", err)
+	}
+	var records []migrationRecord
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+	}
+	applied := make(map[int]time.Time, len(records))
+	for _, record := range records {
+		applied[record.Version] = record.AppliedAt
+	}
+
+	status := make([]models.Migration, 0, len(migrations))
+	for _, m := range migrations {
+		s := models.Migration{Version: m.version, Description: m.description}
+		if appliedAt, ok := applied[m.version]; ok {
+			s.AppliedAt = &appliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+func (r *repo) MigrateUp(ctx context.Context, target int) ([]models.Migration, error) {
+	status, err := r.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []models.Migration
+	for i, m := range migrations {
+		if status[i].AppliedAt != nil || (target > 0 && m.version > target) {
+			continue
+		}
+		slog.Info("applying migration", "version", m.version, "description", m.description)
+		if err = m.up(ctx, r); err != nil {
+			return applied, fmt.Errorf("migration %d failed: %w", m.version, err)
+		}
+		now := time.Now().UTC()
+		_, err = r.migrationsCollection.ReplaceOne(ctx, bson.M{"_id": m.version},
+			migrationRecord{Version: m.version, Description: m.description, AppliedAt: now},
+			options.Replace().SetUpsert(true))
+		if err != nil {
+			return applied, fmt.Errorf("failed to record migration %d: %w", m.version, err)
+		}
+		applied = append(applied, models.Migration{Version: m.version, Description: m.description, AppliedAt: &now})
+	}
+	return applied, nil
+}
+
+func (r *repo) MigrateDown(ctx context.Context, target int) ([]models.Migration, error) {
+	status, err := r.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []models.Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if status[i].AppliedAt == nil || m.version <= target {
+			continue
+		}
+		slog.Info("reverting migration", "version", m.version, "description", m.description)
+		if err = m.down(ctx, r); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d failed: %w", m.version, err)
+		}
+		if _, err = r.migrationsCollection.DeleteOne(ctx, bson.M{"_id": m.version}); err != nil {
+			return reverted, fmt.Errorf("failed to record reverted migration %d: %w", m.version, err)
+		}
+		reverted = append(reverted, models.Migration{Version: m.version, Description: m.description})
+	}
+	return reverted, nil
+}
@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// publishedOutboxRetention is how long published events are kept for debugging before MongoDB removes them.
+const publishedOutboxRetention = 7 * 24 * time.Hour
+
+func (r *repo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// nested calls join the transaction that is already running
+	if !r.transactions || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (any, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+func (r *repo) WriteOutboxEvent(ctx context.Context, event models.Event) error {
+	record := models.OutboxRecord{
+		ID:            event.ID,
+		Event:         event,
+		CreatedAt:     event.Timestamp,
+		NextAttemptAt: event.Timestamp,
+	}
+	if _, err := r.outboxCollection.InsertOne(ctx, record); err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxRecord, error) {
+	records := make([]models.OutboxRecord, 0, limit)
+	for range limit {
+		now := time.Now().UTC()
+		filter := bson.M{
+			"publishedat":   nil,
+			"nextattemptat": bson.M{"$lte": now},
+			"lockeduntil":   bson.M{"$lte": now},
+		}
+		update := bson.M{"$set": bson.M{"lockeduntil": now.Add(lease)}}
+		opts := options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "createdat", Value: 1}}).
+			SetReturnDocument(options.After)
+
+		var record models.OutboxRecord
+		err := r.outboxCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&record)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return records, fmt.Errorf("failed to claim outbox event: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (r *repo) MarkOutboxEventPublished(ctx context.Context, id string) error {
+	update := bson.M{"$set": bson.M{"publishedat": time.Now().UTC()}}
+	if _, err := r.outboxCollection.UpdateOne(ctx, bson.M{"id": id}, update); err != nil {
+		return fmt.Errorf("failed to mark outbox event as published: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) MarkOutboxEventFailed(ctx context.Context, id, lastError string, nextAttemptAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"lasterror": lastError, "nextattemptat": nextAttemptAt, "lockeduntil": time.Time{}},
+		"$inc": bson.M{"attempts": 1},
+	}
+	if _, err := r.outboxCollection.UpdateOne(ctx, bson.M{"id": id}, update); err != nil {
+		return fmt.Errorf("failed to mark outbox event as failed: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) ReleaseOutboxEvents(ctx context.Context, ids []string) error {
+	filter := bson.M{"id": bson.M{"$in": ids}, "publishedat": nil}
+	if _, err := r.outboxCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"lockeduntil": time.Time{}}}); err != nil {
+		return fmt.Errorf("failed to release outbox events: %w", err)
+	}
+	return nil
+}
+
+// supportsTransactions reports whether the deployment is a replica set or a sharded cluster.
+func supportsTransactions(client *mongo.Client) bool {
+	var hello bson.M
+	if err := client.Database("admin").RunCommand(context.Background(), bson.M{"hello": 1}).Decode(&hello); err != nil {
+		slog.Error("failed to get MongoDB topology", "error", err.Error())
+		return false
+	}
+	_, replicaSet := hello["setName"]
+	return replicaSet || hello["msg"] == "isdbgrid"
+}
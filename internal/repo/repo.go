@@ -2,34 +2,123 @@ package repo
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/metrics"
 	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/auditchain"
+	"github.com/avran02/authentication/internal/tracing"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Repo interface {
+	// WithTransaction runs fn in a transaction. Only with db.allow_standalone on a standalone server
+	// fn runs without one. Repo calls inside fn must use the ctx passed to it.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateUser(ctx context.Context, user models.User) error
 	FindUserByUsername(ctx context.Context, username string) (*models.User, error)
+	FindUserByEmail(ctx context.Context, email string) (*models.User, error)
+	FindUserByID(ctx context.Context, userID string) (*models.User, error)
+	// UpdateUser sets the changed fields of the user only.
+	UpdateUser(ctx context.Context, userID string, changes models.UserChanges) error
+	UpdateUserPassword(ctx context.Context, userID, passwordHash string) error
+	SoftDeleteUser(ctx context.Context, userID string, deletedAt time.Time) error
+	// PurgeDeletedUsers removes users deleted before the given time together with their tokens.
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetUserSessions(ctx context.Context, userID string) ([]models.Session, error)
+	ListUsers(ctx context.Context, filter models.UserFilter, offset, limit int64) ([]models.User, int64, error)
+	DeleteUser(ctx context.Context, userID string) error
+	// WriteAuditEvent appends the event to the hash chained audit trail.
+	WriteAuditEvent(ctx context.Context, event models.AuditEvent) error
+	// IterateAuditChain calls fn for every chained audit event in sequence order until fn returns an error.
+	IterateAuditChain(ctx context.Context, fn func(event models.AuditEvent) error) error
+	// AuditChainHead returns the seq and hash of the newest chained event as recorded when it was
+	// written, 0 and "" if none was.
+	AuditChainHead(ctx context.Context) (seq int64, hash string, err error)
+	// CountUnchainedAuditEvents counts events written before the audit trail was hash chained.
+	CountUnchainedAuditEvents(ctx context.Context) (int64, error)
+	// FindAuditEvents returns matching events, newest first. A zero limit returns all of them.
+	FindAuditEvents(ctx context.Context, filter models.AuditFilter, offset, limit int64) ([]models.AuditEvent, int64, error)
 	DeleteAllUserTokens(ctx context.Context, userID string) error
 	WriteRefreshToken(ctx context.Context, userID, accessTokenID, refreshToken string) error
 	GetRefreshTokenInfo(ctx context.Context, userID string) (writtenRefreshTokenHash, writtenAccessTokenID string, err error)
+	CountSessions(ctx context.Context) (int64, error)
+	// Ping checks that MongoDB is reachable.
+	Ping(ctx context.Context) error
+	// MigrationStatus lists the migrations, the applied ones with the time they were applied.
+	MigrationStatus(ctx context.Context) ([]models.Migration, error)
+	// MigrateUp applies the pending migrations up to version target, all of them if target is 0.
+	MigrateUp(ctx context.Context, target int) ([]models.Migration, error)
+	// MigrateDown reverts the applied migrations newer than version target.
+	MigrateDown(ctx context.Context, target int) ([]models.Migration, error)
+	// Close disconnects from MongoDB, waiting for in-use connections until ctx is done.
+	Close(ctx context.Context) error
+	CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error
+	ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, endpointID string) error
+	// WriteWebhookDeadLetter stores a delivery that failed after all retries.
+	WriteWebhookDeadLetter(ctx context.Context, deadLetter models.WebhookDeadLetter) error
+	// FindWebhookDeadLetters returns dead letters, newest first.
+	FindWebhookDeadLetters(ctx context.Context, offset, limit int64) ([]models.WebhookDeadLetter, int64, error)
+	// WriteOutboxEvent stores an event to be published. Call it in the transaction of the state change.
+	WriteOutboxEvent(ctx context.Context, event models.Event) error
+	// ClaimOutboxEvents locks up to limit unpublished events due for publishing, oldest first, for the lease duration.
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxRecord, error)
+	MarkOutboxEventPublished(ctx context.Context, id string) error
+	// MarkOutboxEventFailed releases the lock and schedules the next attempt.
+	MarkOutboxEventFailed(ctx context.Context, id, lastError string, nextAttemptAt time.Time) error
+	// ReleaseOutboxEvents releases the locks of claimed events without counting an attempt.
+	ReleaseOutboxEvents(ctx context.Context, ids []string) error
+	// WriteRevocation assigns the next seq to the revocation and stores it. Call it in the transaction of the revocation.
+	WriteRevocation(ctx context.Context, revocation models.Revocation) error
+	// FindRevocations returns up to limit revocations with seq greater than afterSeq in seq order.
+	FindRevocations(ctx context.Context, afterSeq int64, limit int64) ([]models.Revocation, error)
+	// RevocationSeqRange returns the seq of the oldest retained revocation, zero if none are retained,
+	// and the last assigned seq, zero if there were no revocations yet.
+	RevocationSeqRange(ctx context.Context) (oldest, latest int64, err error)
 }
 
 type repo struct {
 	client           *mongo.Client
 	userCollection   *mongo.Collection
 	tokensCollection *mongo.Collection
+	auditCollection  *mongo.Collection
+
+	webhooksCollection    *mongo.Collection
+	deadLettersCollection *mongo.Collection
+	outboxCollection      *mongo.Collection
+	revocationsCollection *mongo.Collection
+	countersCollection    *mongo.Collection
+	migrationsCollection  *mongo.Collection
+
+	// transactions is false for standalone servers, which don't support them, with db.allow_standalone.
+	transactions bool
 }
 
 func (r *repo) CreateUser(ctx context.Context, user models.User) error {
+	if user.Email != nil {
+		email := normalizeEmail(*user.Email)
+		user.Email = &email
+	}
+
 	_, err := r.userCollection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateUser
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
@@ -37,8 +126,20 @@ func (r *repo) CreateUser(ctx context.Context, user models.User) error {
 }
 
 func (r *repo) FindUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findUser(ctx, bson.M{"username": username})
+}
+
+func (r *repo) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findUser(ctx, bson.M{"email": normalizeEmail(email)}, options.FindOne().SetCollation(emailCollation))
+}
+
+func (r *repo) FindUserByID(ctx context.Context, userID string) (*models.User, error) {
+	return r.findUser(ctx, bson.M{"id": userID})
+}
+
+func (r *repo) findUser(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*models.User, error) {
 	var user *models.User
-	err := r.userCollection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	err := r.userCollection.FindOne(ctx, filter, opts...).Decode(&user)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrUserNotFound
@@ -46,9 +147,340 @@ func (r *repo) FindUserByUsername(ctx context.Context, username string) (*models
 		return nil, err
 	}
 
+	user.Metadata = normalizeMetadata(user.Metadata)
 	return user, nil
 }
 
+// normalizeMetadata replaces the documents and arrays the driver decodes nested metadata into
+// with map[string]any and []any, so the metadata converts to JSON and protobuf structs.
+func normalizeMetadata(metadata map[string]any) map[string]any {
+	for key, value := range metadata {
+		metadata[key] = normalizeMetadataValue(value)
+	}
+	return metadata
+}
+
+func normalizeMetadataValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return normalizeMetadata(v)
+	case bson.M:
+		return normalizeMetadata(v)
+	case bson.D:
+		m := make(map[string]any, len(v))
+		for _, e := range v {
+			m[e.Key] = normalizeMetadataValue(e.Value)
+		}
+		return m
+	case []any:
+		for i := range v {
+			v[i] = normalizeMetadataValue(v[i])
+		}
+		return v
+	case bson.A:
+		return normalizeMetadataValue([]any(v))
+	default:
+		return value
+	}
+}
+
+func (r *repo) UpdateUser(ctx context.Context, userID string, changes models.UserChanges) error {
+	set := bson.M{"updatedat": changes.UpdatedAt}
+	setIfChanged(set, "username", changes.Username)
+	if changes.Email != nil {
+		set["email"] = normalizeEmail(*changes.Email)
+	}
+	setIfChanged(set, "emailverified", changes.EmailVerified)
+	setIfChanged(set, "password", changes.Password)
+	setIfChanged(set, "displayname", changes.DisplayName)
+	setIfChanged(set, "avatarurl", changes.AvatarURL)
+	setIfChanged(set, "locale", changes.Locale)
+	setIfChanged(set, "status", changes.Status)
+	if changes.Metadata != nil {
+		set["metadata"] = changes.Metadata
+	}
+	if changes.Roles != nil {
+		set["roles"] = changes.Roles
+	}
+
+	res, err := r.userCollection.UpdateOne(ctx, bson.M{"id": userID}, bson.M{"$set": set})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateUser
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func setIfChanged[T any](set bson.M, field string, value *T) {
+	if value != nil {
+		set[field] = *value
+	}
+}
+
+func (r *repo) UpdateUserPassword(ctx context.Context, userID, passwordHash string) error {
+	res, err := r.userCollection.UpdateOne(ctx, bson.M{"id": userID}, bson.M{"$set": bson.M{"password": passwordHash}})
+	if err != nil {
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *repo) SoftDeleteUser(ctx context.Context, userID string, deletedAt time.Time) error {
+	res, err := r.userCollection.UpdateOne(ctx, bson.M{"id": userID}, bson.M{"$set": bson.M{"deletedat": deletedAt}})
+	if err != nil {
+		return fmt.Errorf("failed to soft delete user: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *repo) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	filter := bson.M{"deletedat": bson.M{"$lte": deletedBefore}}
+	cursor, err := r.userCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"id": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find deleted users: %w", err)
+	}
+
+	var users []struct {
+		ID string `bson:"id"`
+	}
+	if err = cursor.All(ctx, &users); err != nil {
+		return 0, fmt.Errorf("failed to decode deleted users: %w", err)
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+
+	if _, err = r.tokensCollection.DeleteMany(ctx, bson.M{"userID": bson.M{"$in": ids}}); err != nil {
+		return 0, fmt.Errorf("failed to delete tokens of deleted users: %w", err)
+	}
+	res, err := r.userCollection.DeleteMany(ctx, bson.M{"id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete users: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *repo) GetUserSessions(ctx context.Context, userID string) ([]models.Session, error) {
+	cursor, err := r.tokensCollection.Find(ctx, bson.M{"userID": userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tokens: %w", err)
+	}
+
+	var tokens []struct {
+		UserID        string    `bson:"userID"`
+		AccessTokenID string    `bson:"accessTokenID"`
+		CreatedAt     time.Time `bson:"createdAt"`
+	}
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode tokens: %w", err)
+	}
+
+	sessions := make([]models.Session, 0, len(tokens))
+	for _, t := range tokens {
+		sessions = append(sessions, models.Session{
+			UserID:        t.UserID,
+			AccessTokenID: t.AccessTokenID,
+			CreatedAt:     t.CreatedAt,
+		})
+	}
+	return sessions, nil
+}
+
+func (r *repo) ListUsers(ctx context.Context, filter models.UserFilter, offset, limit int64) ([]models.User, int64, error) {
+	query := bson.M{}
+	if filter.Query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+		query["$or"] = bson.A{
+			bson.M{"username": pattern},
+			bson.M{"email": pattern},
+			bson.M{"displayname": pattern},
+		}
+	}
+	if filter.Role != "" {
+		query["roles"] = filter.Role
+	}
+	switch filter.Status {
+	case "":
+	case models.StatusActive:
+		query["status"] = bson.M{"$in": bson.A{models.StatusActive, "", nil}}
+	default:
+		query["status"] = filter.Status
+	}
+
+	total, err := r.userCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: -1}}).SetSkip(offset).SetLimit(limit)
+	cursor, err := r.userCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find users: %w", err)
+	}
+
+	users := []models.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode users: %w", err)
+	}
+	for i := range users {
+		users[i].Metadata = normalizeMetadata(users[i].Metadata)
+	}
+	return users, total, nil
+}
+
+func (r *repo) DeleteUser(ctx context.Context, userID string) error {
+	if _, err := r.tokensCollection.DeleteMany(ctx, bson.M{"userID": userID}); err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
+
+	res, err := r.userCollection.DeleteOne(ctx, bson.M{"id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *repo) WriteAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	return r.WithTransaction(ctx, func(ctx context.Context) error {
+		// the counter holds the seq and hash of the chain head. Its update conflicts with concurrent
+		// appends until the transaction commits, so each event links to the one committed before it
+		var head struct {
+			Seq  int64
+			Hash string
+		}
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+		err := r.countersCollection.FindOneAndUpdate(
+			ctx, bson.M{"id": auditCounter}, bson.M{"$inc": bson.M{"seq": 1}}, opts,
+		).Decode(&head)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("failed to increment audit counter: %w", err)
+		}
+
+		var prev *models.AuditEvent
+		if head.Seq > 0 {
+			prev = &models.AuditEvent{Seq: head.Seq, Hash: head.Hash}
+		}
+		linked, err := auditchain.Link(event, prev)
+		if err != nil {
+			return fmt.Errorf("failed to link audit event: %w", err)
+		}
+
+		_, err = r.countersCollection.UpdateOne(ctx, bson.M{"id": auditCounter}, bson.M{"$set": bson.M{"hash": linked.Hash}})
+		if err != nil {
+			return fmt.Errorf("failed to update audit counter: %w", err)
+		}
+		if _, err = r.auditCollection.InsertOne(ctx, linked); err != nil {
+			return fmt.Errorf("failed to insert audit event: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *repo) IterateAuditChain(ctx context.Context, fn func(event models.AuditEvent) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	cursor, err := r.auditCollection.Find(ctx, chainedAuditEvents, opts)
+	if err != nil {
+		return fmt.Errorf("failed to find audit events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.AuditEvent
+		if err = cursor.Decode(&event); err != nil {
+			return fmt.Errorf("failed to decode audit event: %w", err)
+		}
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate audit events: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) AuditChainHead(ctx context.Context) (int64, string, error) {
+	var head struct {
+		Seq  int64
+		Hash string
+	}
+	err := r.countersCollection.FindOne(ctx, bson.M{"id": auditCounter}).Decode(&head)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, "", fmt.Errorf("failed to read audit counter: %w", err)
+	}
+	return head.Seq, head.Hash, nil
+}
+
+func (r *repo) CountUnchainedAuditEvents(ctx context.Context) (int64, error) {
+	count, err := r.auditCollection.CountDocuments(ctx, bson.M{"seq": bson.M{"$not": bson.M{"$gt": 0}}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repo) FindAuditEvents(ctx context.Context, filter models.AuditFilter, offset, limit int64) ([]models.AuditEvent, int64, error) {
+	query := bson.M{}
+	if filter.UserID != "" {
+		query["$or"] = bson.A{bson.M{"userid": filter.UserID}, bson.M{"actorid": filter.UserID}}
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lt"] = filter.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	total, err := r.auditCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetSkip(offset)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.auditCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find audit events: %w", err)
+	}
+
+	events := []models.AuditEvent{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode audit events: %w", err)
+	}
+	return events, total, nil
+}
+
 func (r *repo) DeleteAllUserTokens(ctx context.Context, userID string) error {
 	_, err := r.tokensCollection.DeleteMany(ctx, bson.M{"userID": userID})
 	if err != nil {
@@ -62,6 +494,7 @@ func (r *repo) WriteRefreshToken(ctx context.Context, userID, accessTokenID, ref
 		"userID":        userID,
 		"accessTokenID": accessTokenID,
 		"refreshToken":  refreshToken,
+		"createdAt":     time.Now().UTC(),
 	}
 
 	_, err := r.tokensCollection.InsertOne(ctx, token)
@@ -83,21 +516,94 @@ func (r *repo) GetRefreshTokenInfo(ctx context.Context, userID string) (string,
 	return token["refreshToken"].(string), token["accessTokenID"].(string), nil
 }
 
+func (r *repo) CountSessions(ctx context.Context) (int64, error) {
+	count, err := r.tokensCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repo) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) Close(ctx context.Context) error {
+	if err := r.client.Disconnect(ctx); err != nil {
+		return fmt.Errorf("failed to disconnect from MongoDB: %w", err)
+	}
+	return nil
+}
+
 func New(conf *config.DB) Repo {
 	client := mustConnectDB(conf)
-	usersCollection := client.Database("auth").Collection("users")
-	tokensCollection := client.Database("auth").Collection("tokens")
+	db := client.Database(conf.Database)
+	names := conf.Collections
+
+	transactions := supportsTransactions(client)
+	if !transactions && !conf.AllowStandalone {
+		log.Fatalf("MongoDB is not a replica set or sharded cluster, transactions are required " +
+			"(set db.allow_standalone to run without them in development)")
+	}
+	if !transactions {
+		slog.Warn("MongoDB is not a replica set, state changes and their events are written without transactions")
+	}
 
 	return &repo{
 		client:           client,
-		userCollection:   usersCollection,
-		tokensCollection: tokensCollection,
+		userCollection:   db.Collection(names.Users),
+		tokensCollection: db.Collection(names.Tokens),
+		auditCollection:  db.Collection(names.Audit),
+
+		webhooksCollection:    db.Collection(names.Webhooks),
+		deadLettersCollection: db.Collection(names.WebhookDeadLetters),
+		outboxCollection:      db.Collection(names.Outbox),
+		revocationsCollection: db.Collection(names.Revocations),
+		countersCollection:    db.Collection(names.Counters),
+		migrationsCollection:  db.Collection(names.Migrations),
+		transactions:          transactions,
+	}
+}
+
+// commandMonitor reports MongoDB commands to the metrics and as trace spans.
+func commandMonitor() *event.CommandMonitor {
+	monitors := []*event.CommandMonitor{metrics.MongoMonitor(), tracing.MongoMonitor()}
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
 	}
 }
 
 func mustConnectDB(config *config.DB) *mongo.Client {
-	dsn := getDsn(config)
-	opt := options.Client().ApplyURI(dsn)
+	opt, err := clientOptions(config)
+	if err != nil {
+		log.Fatalf("invalid MongoDB configuration: %s", err)
+	}
+	// decoding nested documents as maps keeps audit event details hashable after a round trip
+	opt.SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true}).
+		SetMonitor(commandMonitor())
 	client, err := mongo.Connect(context.Background(), opt)
 	if err != nil {
 		log.Fatalf("failed to connect to MongoDB: %s", err)
@@ -112,6 +618,81 @@ func mustConnectDB(config *config.DB) *mongo.Client {
 	return client
 }
 
-func getDsn(config *config.DB) string {
-	return fmt.Sprintf("mongodb://%s:%s@%s:%s", config.User, config.Password, config.Host, config.Port)
+const auditCounter = "audit"
+
+var chainedAuditEvents = bson.M{"seq": bson.M{"$gt": 0}}
+
+// emailCollation makes email comparisons case-insensitive for users stored before emails were normalized.
+var emailCollation = &options.Collation{Locale: "en", Strength: 2} //nolint:mnd
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// clientOptions starts from db.uri, or from the hosts, and applies the other connection keys that are set.
+func clientOptions(conf *config.DB) (*options.ClientOptions, error) {
+	opt := options.Client()
+	switch {
+	case conf.URI != "":
+		opt.ApplyURI(conf.URI)
+	case len(conf.Hosts) > 0:
+		opt.SetHosts(conf.Hosts)
+	default:
+		opt.SetHosts([]string{net.JoinHostPort(conf.Host, conf.Port)})
+	}
+
+	if conf.User != "" {
+		opt.SetAuth(options.Credential{Username: conf.User, Password: conf.Password, AuthSource: conf.AuthSource})
+	} else if conf.AuthSource != "" && opt.Auth != nil {
+		opt.Auth.AuthSource = conf.AuthSource
+	}
+	if conf.ReplicaSet != "" {
+		opt.SetReplicaSet(conf.ReplicaSet)
+	}
+	if conf.ConnectTimeout > 0 {
+		opt.SetConnectTimeout(conf.ConnectTimeout)
+	}
+	if conf.ServerSelectionTimeout > 0 {
+		opt.SetServerSelectionTimeout(conf.ServerSelectionTimeout)
+	}
+	if conf.MaxPoolSize > 0 {
+		opt.SetMaxPoolSize(conf.MaxPoolSize)
+	}
+	if conf.MinPoolSize > 0 {
+		opt.SetMinPoolSize(conf.MinPoolSize)
+	}
+
+	if conf.TLS.Enabled {
+		tlsConfig, err := tlsConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opt.SetTLSConfig(tlsConfig)
+	}
+	return opt, opt.Validate()
+}
+
+func tlsConfig(conf config.DBTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: conf.InsecureSkipVerify, //nolint:gosec
+	}
+	if conf.CAFile != "" {
+		ca, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read db.tls.ca_file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no PEM certificates in db.tls.ca_file %s", conf.CAFile)
+		}
+	}
+	if conf.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load the db.tls client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
 }
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revocationRetention is how long revocations stay available to the revocation feed.
+const revocationRetention = 7 * 24 * time.Hour
+
+const revocationsCounter = "revocations"
+
+func (r *repo) WriteRevocation(ctx context.Context, revocation models.Revocation) error {
+	// inside a transaction the counter update conflicts with concurrent revocations until they
+	// commit, so revocations become visible in the order of their seq
+	var counter struct{ Seq int64 }
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.countersCollection.FindOneAndUpdate(
+		ctx, bson.M{"id": revocationsCounter}, bson.M{"$inc": bson.M{"seq": 1}}, opts,
+	).Decode(&counter)
+	if err != nil {
+		return fmt.Errorf("failed to increment revocation counter: %w", err)
+	}
+
+	revocation.Seq = counter.Seq
+	if _, err = r.revocationsCollection.InsertOne(ctx, revocation); err != nil {
+		return fmt.Errorf("failed to insert revocation: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) FindRevocations(ctx context.Context, afterSeq int64, limit int64) ([]models.Revocation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(limit)
+	cursor, err := r.revocationsCollection.Find(ctx, bson.M{"seq": bson.M{"$gt": afterSeq}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find revocations: %w", err)
+	}
+
+	revocations := []models.Revocation{}
+	if err = cursor.All(ctx, &revocations); err != nil {
+		return nil, fmt.Errorf("failed to decode revocations: %w", err)
+	}
+	return revocations, nil
+}
+
+func (r *repo) RevocationSeqRange(ctx context.Context) (oldest, latest int64, err error) {
+	var revocation models.Revocation
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: 1}})
+	err = r.revocationsCollection.FindOne(ctx, bson.M{}, opts).Decode(&revocation)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, 0, fmt.Errorf("failed to find oldest revocation: %w", err)
+	}
+
+	var counter struct{ Seq int64 }
+	err = r.countersCollection.FindOne(ctx, bson.M{"id": revocationsCounter}).Decode(&counter)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, 0, fmt.Errorf("failed to read revocation counter: %w", err)
+	}
+	return revocation.Seq, counter.Seq, nil
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avran02/authentication/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *repo) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	if _, err := r.webhooksCollection.InsertOne(ctx, endpoint); err != nil {
+		return fmt.Errorf("failed to insert webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}})
+	cursor, err := r.webhooksCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook endpoints: %w", err)
+	}
+
+	endpoints := []models.WebhookEndpoint{}
+	if err = cursor.All(ctx, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (r *repo) DeleteWebhookEndpoint(ctx context.Context, endpointID string) error {
+	res, err := r.webhooksCollection.DeleteOne(ctx, bson.M{"id": endpointID})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+func (r *repo) WriteWebhookDeadLetter(ctx context.Context, deadLetter models.WebhookDeadLetter) error {
+	if _, err := r.deadLettersCollection.InsertOne(ctx, deadLetter); err != nil {
+		return fmt.Errorf("failed to insert webhook dead letter: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) FindWebhookDeadLetters(ctx context.Context, offset, limit int64) ([]models.WebhookDeadLetter, int64, error) {
+	total, err := r.deadLettersCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook dead letters: %w", err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "failedat", Value: -1}}).SetSkip(offset).SetLimit(limit)
+	cursor, err := r.deadLettersCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find webhook dead letters: %w", err)
+	}
+
+	deadLetters := []models.WebhookDeadLetter{}
+	if err = cursor.All(ctx, &deadLetters); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode webhook dead letters: %w", err)
+	}
+	return deadLetters, total, nil
+}
@@ -0,0 +1,8 @@
+package secrets
+
+import "errors"
+
+var (
+	ErrNotConfigured  = errors.New("secret provider is not configured")
+	ErrSecretNotFound = errors.New("secret not found")
+)
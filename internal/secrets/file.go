@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// FileProvider reads secrets from files, like the ones Docker and Kubernetes mount.
+// Trailing newlines are dropped.
+type FileProvider struct{}
+
+func (FileProvider) Get(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,54 @@
+// Package secrets resolves secret references like "file:/run/secrets/jwt" or
+// "vault:secret/auth#jwt_secret" to the secrets they point to.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Provider looks up a secret by the part of the reference after the scheme.
+type Provider interface {
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver picks the provider by the scheme of a reference.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver resolves "file:" and "vault:" references. References to Vault fail if vault has no address.
+func NewResolver(vault VaultOptions) *Resolver {
+	return &Resolver{providers: map[string]Provider{
+		"file":  FileProvider{},
+		"vault": NewVaultProvider(vault),
+	}}
+}
+
+// Register makes provider resolve the references with scheme.
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// IsReference reports whether value names a secret of a registered provider instead of being one.
+func (r *Resolver) IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, ":")
+	_, known := r.providers[scheme]
+	return ok && known
+}
+
+// Resolve returns the secret value references, or value itself if it isn't a reference.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	provider, known := r.providers[scheme]
+	if !ok || !known {
+		return value, nil
+	}
+
+	secret, err := provider.Get(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("can't resolve %s secret %q: %w", scheme, ref, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultOptions configures the access to a Vault KV secrets engine.
+type VaultOptions struct {
+	Address string
+	Token   string
+	// KVVersion is 1 or 2, the version of the KV engine.
+	KVVersion int
+	Timeout   time.Duration
+}
+
+// VaultProvider reads secrets from the KV engine of Vault over its HTTP API. References are
+// "<mount>/<path>#<key>", e.g. "secret/auth#jwt_secret". Secrets are read once and cached,
+// make a new provider to read them again.
+type VaultProvider struct {
+	opts   VaultOptions
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]map[string]any
+}
+
+func NewVaultProvider(opts VaultOptions) *VaultProvider {
+	return &VaultProvider{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		cache:  make(map[string]map[string]any),
+	}
+}
+
+func (p *VaultProvider) Get(ctx context.Context, ref string) (string, error) {
+	if p.opts.Address == "" {
+		return "", fmt.Errorf("%w: vault address is not set", ErrNotConfigured)
+	}
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || key == "" {
+		return "", fmt.Errorf("%w: vault references need a #key", ErrSecretNotFound)
+	}
+
+	data, err := p.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: no string %q in %s", ErrSecretNotFound, key, path)
+	}
+	return value, nil
+}
+
+func (p *VaultProvider) read(ctx context.Context, path string) (map[string]any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if data, ok := p.cache[path]; ok {
+		return data, nil
+	}
+
+	apiPath := path
+	if p.opts.KVVersion == 2 { //nolint:mnd
+		mount, rest, _ := strings.Cut(path, "/")
+		apiPath = mount + "/data/" + rest
+	}
+	endpoint, err := url.JoinPath(p.opts.Address, "v1", apiPath)
+	if err != nil {
+		return nil, fmt.Errorf("bad vault address: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.opts.Token)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault answered %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	data := body.Data
+	if p.opts.KVVersion == 2 { //nolint:mnd
+		// KV v2 wraps the secret with its metadata
+		data, _ = body.Data["data"].(map[string]any)
+	}
+	p.cache[path] = data
+	return data, nil
+}
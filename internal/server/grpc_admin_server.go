@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/avran02/authentication/internal/controller"
+	pb "github.com/avran02/authentication/pb"
+)
+
+type GrpcAdminServer struct {
+	pb.UnimplementedAdminServiceServer
+	controller controller.Controller
+}
+
+func (s GrpcAdminServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	slog.Info("Admin: listing users")
+	return s.controller.AdminListUsers(ctx, req)
+}
+
+func (s GrpcAdminServer) GetUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.User, error) {
+	slog.Info("Admin: getting user", "userID", req.UserId)
+	return s.controller.AdminGetUser(ctx, req)
+}
+
+func (s GrpcAdminServer) DisableUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	slog.Info("Admin: disabling user", "userID", req.UserId)
+	return s.controller.AdminDisableUser(ctx, req)
+}
+
+func (s GrpcAdminServer) EnableUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	slog.Info("Admin: enabling user", "userID", req.UserId)
+	return s.controller.AdminEnableUser(ctx, req)
+}
+
+func (s GrpcAdminServer) ForceLogout(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	slog.Info("Admin: force logout", "userID", req.UserId)
+	return s.controller.AdminForceLogout(ctx, req)
+}
+
+func (s GrpcAdminServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	slog.Info("Admin: resetting password", "userID", req.UserId)
+	return s.controller.AdminResetPassword(ctx, req)
+}
+
+func (s GrpcAdminServer) SetUserStatus(ctx context.Context, req *pb.SetUserStatusRequest) (*pb.AdminResponse, error) {
+	slog.Info("Admin: setting user status", "userID", req.UserId, "status", req.Status)
+	return s.controller.AdminSetUserStatus(ctx, req)
+}
+
+func (s GrpcAdminServer) SetUserRoles(ctx context.Context, req *pb.SetUserRolesRequest) (*pb.AdminResponse, error) {
+	slog.Info("Admin: setting user roles", "userID", req.UserId)
+	return s.controller.AdminSetUserRoles(ctx, req)
+}
+
+func (s GrpcAdminServer) DeleteUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminResponse, error) {
+	slog.Info("Admin: deleting user", "userID", req.UserId)
+	return s.controller.AdminDeleteUser(ctx, req)
+}
@@ -5,19 +5,60 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
-	"os"
+	"runtime/debug"
+	"time"
 
 	"github.com/avran02/authentication/internal/config"
 	"github.com/avran02/authentication/internal/controller"
+	healthcheck "github.com/avran02/authentication/internal/health"
+	"github.com/avran02/authentication/internal/metrics"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
 	pb "github.com/avran02/authentication/pb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
+// healthCheckInterval is how often the gRPC health status is updated from the checker.
+const healthCheckInterval = 5 * time.Second
+
+// grpcServices are the services whose health status is reported, "" stands for the whole server.
+var grpcServices = []string{"", "authservice"}
+
 type GrpcServer struct {
 	pb.UnimplementedAuthServiceServer
 	controller.Controller
+
+	server  *grpc.Server
+	health  *health.Server
+	checker *healthcheck.Checker
+	// draining is closed on shutdown to end the streams, which GracefulStop would wait for.
+	draining chan struct{}
+}
+
+func (s GrpcServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	slog.Info("Registering user")
+	return s.Controller.GrpcRegister(ctx, req)
+}
+
+func (s GrpcServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	slog.Info("Logging in user")
+	return s.Controller.GrpcLogin(ctx, req)
+}
+
+func (s GrpcServer) RefreshTokens(ctx context.Context, req *pb.RefreshTokensRequest) (*pb.RefreshTokensResponse, error) {
+	slog.Info("Refreshing tokens")
+	return s.Controller.GrpcRefreshTokens(ctx, req)
+}
+
+func (s GrpcServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	slog.Info("Logging out user")
+	return s.Controller.GrpcLogout(ctx, req)
 }
 
 func (s GrpcServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
@@ -25,35 +66,171 @@ func (s GrpcServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequ
 	return s.Controller.ValidateToken(ctx, req)
 }
 
-func (s GrpcServer) Run(config config.Server) {
+func (s GrpcServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	slog.Info("Getting user")
+	return s.Controller.GetUser(ctx, req)
+}
+
+func (s GrpcServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	slog.Info("Updating user")
+	return s.Controller.UpdateUser(ctx, req)
+}
+
+func (s GrpcServer) WatchRevocations(req *pb.WatchRevocationsRequest, stream pb.AuthService_WatchRevocationsServer) error {
+	slog.Info("Watching revocations", "cursor", req.Cursor)
+	return s.Controller.WatchRevocations(req, stream)
+}
+
+// Run serves until Shutdown is called.
+func (s GrpcServer) Run(config config.Server) error {
 	serverEndpoint := fmt.Sprintf("%s:%s", config.Host, config.GRPCPort)
 	slog.Info("Starting gRPC server on " + serverEndpoint)
 	lis, err := net.Listen("tcp", serverEndpoint)
 	if err != nil {
-		slog.Error(fmt.Sprintf("can't listen on %s: \n%s", serverEndpoint, err.Error()))
-		os.Exit(1)
+		return fmt.Errorf("can't listen on %s: %w", serverEndpoint, err)
 	}
 
+	go s.watchHealth()
 	slog.Info("Listening on " + serverEndpoint)
-	var opts []grpc.ServerOption
+	if err = s.server.Serve(lis); err != nil {
+		return fmt.Errorf("can't start grpc server: %w", err)
+	}
+	return nil
+}
+
+// Shutdown reports NOT_SERVING to health checks, stops accepting calls and waits for
+// in-flight calls until ctx is done. Calls still running then are canceled.
+func (s GrpcServer) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+	close(s.draining)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Warn("gRPC calls didn't finish in time, canceling them")
+		s.server.Stop()
+	}
+}
+
+// watchHealth updates the health status of the services from the checker until shutdown.
+func (s GrpcServer) watchHealth() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
 
-	grpcServer := grpc.NewServer(opts...)
-	pb.RegisterAuthServiceServer(grpcServer, s)
+	for {
+		s.updateHealth()
+		select {
+		case <-s.draining:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s GrpcServer) updateHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckInterval)
+	defer cancel()
+
+	servingStatus := grpc_health_v1.HealthCheckResponse_SERVING
+	if !s.checker.Ready(ctx) {
+		servingStatus = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	for _, service := range grpcServices {
+		s.health.SetServingStatus(service, servingStatus)
+	}
+}
+
+// requestInfoInterceptor stores the peer address and user agent for the audit trail.
+func requestInfoInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var info requestinfo.Info
+	if p, ok := peer.FromContext(ctx); ok {
+		info.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(info.IP); err == nil {
+			info.IP = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			info.UserAgent = ua[0]
+		}
+	}
+	return handler(requestinfo.WithInfo(ctx, info), req)
+}
+
+// recoveryInterceptor turns a panic of a handler into an Internal error instead of crashing the server,
+// like middleware.Recoverer does for HTTP.
+func recoveryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+	defer recoverPanic(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func streamRecoveryInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverPanic(info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+func recoverPanic(method string, err *error) {
+	if r := recover(); r != nil {
+		slog.Error("panic in gRPC handler", "method", method, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
 
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("authservice", grpc_health_v1.HealthCheckResponse_SERVING)
+// drainInterceptor cancels streams on shutdown and tells clients to reconnect to another instance.
+func (s GrpcServer) drainInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, cancel := context.WithCancel(ss.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.draining:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	slog.Info("Starting gRPC server")
-	if err = grpcServer.Serve(lis); err != nil {
-		slog.Error(fmt.Sprintf("can't start grpc server: \n%s", err.Error()))
-		os.Exit(1)
+	err := handler(srv, &drainedStream{ServerStream: ss, ctx: ctx})
+	select {
+	case <-s.draining:
+		return status.Error(codes.Unavailable, "server is shutting down")
+	default:
+		return err
 	}
 }
 
-func newGrpcServer(controller controller.Controller) *GrpcServer {
-	return &GrpcServer{
+type drainedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *drainedStream) Context() context.Context {
+	return s.ctx
+}
+
+func newGrpcServer(controller controller.Controller, checker *healthcheck.Checker) *GrpcServer {
+	s := &GrpcServer{
 		UnimplementedAuthServiceServer: pb.UnimplementedAuthServiceServer{},
 		Controller:                     controller,
+		health:                         health.NewServer(),
+		checker:                        checker,
+		draining:                       make(chan struct{}),
+	}
+	s.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor, recoveryInterceptor, requestInfoInterceptor),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor, streamRecoveryInterceptor, s.drainInterceptor),
+	)
+
+	pb.RegisterAuthServiceServer(s.server, s)
+	pb.RegisterAdminServiceServer(s.server, GrpcAdminServer{controller: controller})
+	grpc_health_v1.RegisterHealthServer(s.server, s.health)
+	// not serving until the first check passed
+	for _, service := range grpcServices {
+		s.health.SetServingStatus(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
 	}
+	return s
 }
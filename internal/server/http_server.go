@@ -1,13 +1,20 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"sync/atomic"
 
 	"github.com/avran02/authentication/internal/config"
 	"github.com/avran02/authentication/internal/controller"
+	"github.com/avran02/authentication/internal/health"
+	"github.com/avran02/authentication/internal/metrics"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
+	"github.com/avran02/authentication/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -17,6 +24,9 @@ import (
 type HTTPServer struct {
 	controller controller.Controller
 	router     *chi.Mux
+	server     *http.Server
+	debug      bool
+	cors       atomic.Pointer[cors.Cors]
 }
 
 func (s *HTTPServer) routes() *chi.Mux {
@@ -26,40 +36,117 @@ func (s *HTTPServer) routes() *chi.Mux {
 	r.Post("/refresh-tokens", s.controller.RefreshTokens)
 	r.Post("/logout", s.controller.Logout)
 
+	r.Group(func(r chi.Router) {
+		r.Use(s.controller.Authenticate)
+		r.Get("/me", s.controller.GetMe)
+		r.Patch("/me", s.controller.UpdateMe)
+		r.Post("/me/delete", s.controller.DeleteMe)
+		r.Get("/me/export", s.controller.ExportMe)
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(s.controller.Authenticate)
+		r.Use(s.controller.RequireAdmin)
+		r.Get("/users", s.controller.ListUsers)
+		r.Get("/users/{id}", s.controller.GetUserByID)
+		r.Post("/users/{id}/disable", s.controller.DisableUser)
+		r.Post("/users/{id}/enable", s.controller.EnableUser)
+		r.Post("/users/{id}/logout", s.controller.ForceLogout)
+		r.Post("/users/{id}/reset-password", s.controller.ResetPassword)
+		r.Put("/users/{id}/status", s.controller.SetUserStatus)
+		r.Put("/users/{id}/roles", s.controller.SetUserRoles)
+		r.Delete("/users/{id}", s.controller.DeleteUser)
+		r.Get("/audit", s.controller.ListAuditEvents)
+		r.Get("/webhooks", s.controller.ListWebhooks)
+		r.Post("/webhooks", s.controller.CreateWebhook)
+		r.Delete("/webhooks/{id}", s.controller.DeleteWebhook)
+		r.Get("/webhooks/dead-letters", s.controller.ListWebhookDeadLetters)
+	})
+
 	return r
 }
 
-func (s *HTTPServer) Run(config config.Server) {
+// Run serves until Shutdown is called.
+func (s *HTTPServer) Run(config config.Server) error {
 	serverEndpoint := fmt.Sprintf("%s:%s", config.Host, config.HTTPPort)
 	slog.Info("Starting http server at " + serverEndpoint)
-	server := http.Server{ //nolint:gosec
-		Addr:    serverEndpoint,
-		Handler: s.router,
-	}
+	s.server.Addr = serverEndpoint
 
-	if err := server.ListenAndServe(); err != nil {
-		slog.Error("can't statr http server", "error", err.Error())
-		os.Exit(1)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("can't start http server: %w", err)
 	}
+	return nil
 }
 
-func newHTTPServer(controller controller.Controller, debug bool, corsConfig config.CORSConfig) *HTTPServer {
-	s := &HTTPServer{
-		controller: controller,
+// Shutdown stops accepting connections and waits for in-flight requests until ctx is done.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to drain http server: %w", err)
 	}
-	corsOpts := cors.Options{
+	return nil
+}
+
+// requestInfo stores the client IP and user agent for the audit trail.
+func requestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		ctx := requestinfo.WithInfo(r.Context(), requestinfo.Info{
+			IP:        ip,
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ReloadCORS applies corsConfig to the requests received from now on.
+func (s *HTTPServer) ReloadCORS(corsConfig config.CORSConfig) {
+	s.cors.Store(cors.New(cors.Options{
 		AllowedOrigins:   corsConfig.AllowedOrigins,
 		AllowedMethods:   corsConfig.AllowedMethods,
 		AllowedHeaders:   corsConfig.AllowedHeaders,
 		AllowCredentials: corsConfig.AllowCredentials,
-		Debug:            debug,
+		Debug:            s.debug,
+	}))
+}
+
+// corsHandler applies the current CORS rules.
+func (s *HTTPServer) corsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.cors.Load().Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// requestIDHeader returns the request ID, taken from the X-Request-Id header or generated, to the client.
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newHTTPServer(controller controller.Controller, checker *health.Checker, debug bool, corsConfig config.CORSConfig) *HTTPServer {
+	s := &HTTPServer{
+		controller: controller,
+		debug:      debug,
 	}
+	s.ReloadCORS(corsConfig)
 
 	main := chi.NewMux()
+	main.Use(tracing.Middleware)
+	main.Use(middleware.RequestID)
+	main.Use(requestIDHeader)
 	main.Use(middleware.Logger)
+	main.Use(metrics.Middleware)
 	main.Use(middleware.Recoverer)
-	main.Use(cors.Handler(corsOpts))
+	main.Use(requestInfo)
+	main.Use(s.corsHandler)
 
+	main.Handle("/metrics", metrics.Handler())
+	main.Get("/healthz", health.LivenessHandler)
+	main.Get("/readyz", checker.ReadinessHandler)
 	main.Get("/docs/openapi.yml", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "./docs/openapi.yml")
 	})
@@ -73,6 +160,9 @@ func newHTTPServer(controller controller.Controller, debug bool, corsConfig conf
 	router := s.routes()
 	main.Mount("/api/v1", router)
 	s.router = main
+	s.server = &http.Server{ //nolint:gosec
+		Handler: main,
+	}
 
 	return s
 }
@@ -1,23 +1,62 @@
 package server
 
 import (
+	"context"
+	"sync"
+
 	"github.com/avran02/authentication/internal/config"
 	"github.com/avran02/authentication/internal/controller"
+	"github.com/avran02/authentication/internal/health"
 )
 
 type Server struct {
 	*HTTPServer
 	*GrpcServer
+	checker *health.Checker
+}
+
+// Run starts both servers. The returned channel receives the error of a server that failed.
+func (s *Server) Run(config config.Server) <-chan error {
+	errs := make(chan error, 2) //nolint:mnd
+	go func() {
+		if err := s.HTTPServer.Run(config); err != nil {
+			errs <- err
+		}
+	}()
+	go func() {
+		if err := s.GrpcServer.Run(config); err != nil {
+			errs <- err
+		}
+	}()
+	return errs
+}
+
+// SetShuttingDown fails the HTTP readiness checks and reports NOT_SERVING to gRPC health checks,
+// while both servers keep serving requests.
+func (s *Server) SetShuttingDown() {
+	s.checker.SetShuttingDown()
+	s.GrpcServer.health.Shutdown()
 }
 
-func (s *Server) Run(config config.Server) {
-	go s.HTTPServer.Run(config)
-	go s.GrpcServer.Run(config)
+// Shutdown fails readiness checks, then drains both servers at once until ctx is done.
+// Call SetShuttingDown some time before to let load balancers notice first.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.SetShuttingDown()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.GrpcServer.Shutdown(ctx)
+	}()
+	err := s.HTTPServer.Shutdown(ctx)
+	wg.Wait()
+	return err
 }
 
-func New(controller controller.Controller, debug bool, corsConfig config.CORSConfig) *Server {
+func New(controller controller.Controller, checker *health.Checker, debug bool, corsConfig config.CORSConfig) *Server {
 	return &Server{
-		HTTPServer: newHTTPServer(controller, debug, corsConfig),
-		GrpcServer: newGrpcServer(controller),
+		HTTPServer: newHTTPServer(controller, checker, debug, corsConfig),
+		GrpcServer: newGrpcServer(controller, checker),
+		checker:    checker,
 	}
 }
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize   = 20
+	maxPageSize       = 100
+	tempPasswordBytes = 12
+)
+
+// pagination clamps page to 1 or more and limit to 1..maxPageSize, a non-positive limit is the
+// default page size, and returns the offset and limit of the page. The offset saturates instead
+// of overflowing for huge pages.
+func pagination(page, limit int) (offset, size int64) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	size = int64(min(limit, maxPageSize))
+	pages := int64(max(page, 1)) - 1
+	if pages > math.MaxInt64/size {
+		return math.MaxInt64, size
+	}
+	return pages * size, size
+}
+
+// AdminService holds operations available to users with the admin role.
+// Callers are expected to check the role with Authenticate before calling them.
+type AdminService interface {
+	ListUsers(ctx context.Context, filter models.UserFilter, page, limit int) (users []models.User, total int64, err error)
+	// CreateUser creates an active user with roles without starting a session. An empty
+	// password is replaced with a random temporary one, which is returned.
+	CreateUser(ctx context.Context, username, password string, email *string, roles []string) (id, createdPassword string, err error)
+	// SetUserStatus revokes all sessions of the user unless the new status is active.
+	SetUserStatus(ctx context.Context, userID string, status models.UserStatus) error
+	// ForceLogout revokes all sessions of the user.
+	ForceLogout(ctx context.Context, userID string) error
+	// ResetPassword sets a new password and revokes all sessions. An empty password
+	// is replaced with a random temporary one, which is returned.
+	ResetPassword(ctx context.Context, userID, password string) (string, error)
+	SetUserRoles(ctx context.Context, userID string, roles []string) error
+	DeleteUser(ctx context.Context, userID string) error
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter, page, limit int) (events []models.AuditEvent, total int64, err error)
+	// CreateWebhookEndpoint registers an endpoint. An empty secret is replaced with a random one.
+	CreateWebhookEndpoint(ctx context.Context, url, secret string, events []models.EventType) (*models.WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, endpointID string) error
+	ListWebhookDeadLetters(ctx context.Context, page, limit int) (deadLetters []models.WebhookDeadLetter, total int64, err error)
+}
+
+func (s *service) ListUsers(ctx context.Context, filter models.UserFilter, page, limit int) (_ []models.User, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListUsers")
+	defer func() { tracing.End(span, err) }()
+	offset, size := pagination(page, limit)
+
+	users, total, err := s.repo.ListUsers(ctx, filter, offset, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+func (s *service) CreateUser(
+	ctx context.Context,
+	username, password string,
+	email *string,
+	roles []string,
+) (id, createdPassword string, err error) {
+	ctx, span := tracing.Start(ctx, "service.CreateUser")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Creating user", "username", username, "roles", roles)
+	defer func() {
+		s.recordAdminAction(ctx, "create_user", id, err, map[string]any{"username": username, "roles": roles})
+	}()
+	if err = s.ensureUsernameAvailable(ctx, username); err != nil {
+		return "", "", err
+	}
+	if email != nil {
+		if err = s.ensureEmailAvailable(ctx, *email); err != nil {
+			return "", "", err
+		}
+	}
+	if password == "" {
+		if password, err = temporaryPassword(); err != nil {
+			return "", "", err
+		}
+	} else if err = s.validatePassword(password); err != nil {
+		return "", "", err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	id = uuid.NewString()
+	now := time.Now().UTC()
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateUser(ctx, models.User{
+			ID:        id,
+			Email:     email,
+			Username:  username,
+			Password:  hashedPassword,
+			Roles:     roles,
+			Status:    models.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return s.writeEvent(ctx, models.EventUserRegistered, id, map[string]any{"username": username})
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return id, password, nil
+}
+
+func (s *service) SetUserStatus(ctx context.Context, userID string, status models.UserStatus) (err error) {
+	ctx, span := tracing.Start(ctx, "service.SetUserStatus")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Setting user status", "userID", userID, "status", status)
+	defer func() { s.recordAdminAction(ctx, "set_status", userID, err, map[string]any{"status": status}) }()
+	if !status.IsValid() {
+		return ErrInvalidStatus
+	}
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.updateUser(ctx, userID, models.UserChanges{Status: &status}); err != nil {
+			return err
+		}
+		if status != models.StatusActive {
+			return s.revokeSessions(ctx, userID, "status_changed")
+		}
+		return nil
+	})
+}
+
+func (s *service) ForceLogout(ctx context.Context, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.ForceLogout")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Force logout", "userID", userID)
+	defer func() { s.recordAdminAction(ctx, "force_logout", userID, err, nil) }()
+	return s.revokeSessions(ctx, userID, "force_logout")
+}
+
+// revokeSessions deletes all tokens of the user, adds the revocation to the revocation feed
+// and publishes session.revoked in the same transaction.
+func (s *service) revokeSessions(ctx context.Context, userID, reason string) error {
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteAllUserTokens(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete all user tokens: %w", err)
+		}
+		err := s.repo.WriteRevocation(ctx, models.Revocation{
+			UserID:    userID,
+			Reason:    reason,
+			RevokedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write revocation: %w", err)
+		}
+		return s.writeEvent(ctx, models.EventSessionRevoked, userID, map[string]any{"reason": reason})
+	})
+}
+
+func (s *service) ResetPassword(ctx context.Context, userID, password string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "service.ResetPassword")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Resetting password", "userID", userID)
+	defer func() {
+		s.recordEvent(ctx, models.AuditPasswordChange, userID, err, map[string]any{"reset": true})
+		s.recordAdminAction(ctx, "reset_password", userID, err, nil)
+	}()
+	if password == "" {
+		if password, err = temporaryPassword(); err != nil {
+			return "", err
+		}
+	} else if err = s.validatePassword(password); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.updateUser(ctx, userID, models.UserChanges{Password: &hashedPassword}); err != nil {
+			return err
+		}
+		if err := s.writeEvent(ctx, models.EventPasswordChanged, userID, map[string]any{"reset": true}); err != nil {
+			return err
+		}
+		return s.revokeSessions(ctx, userID, "password_reset")
+	})
+	if err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// SetUserRoles also revokes the sessions of the user, because roles are embedded in access tokens.
+func (s *service) SetUserRoles(ctx context.Context, userID string, roles []string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.SetUserRoles")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Setting user roles", "userID", userID, "roles", roles)
+	defer func() { s.recordAdminAction(ctx, "set_roles", userID, err, map[string]any{"roles": roles}) }()
+	// nil roles would leave the roles untouched instead of removing them
+	if roles == nil {
+		roles = []string{}
+	}
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.updateUser(ctx, userID, models.UserChanges{Roles: roles}); err != nil {
+			return err
+		}
+		return s.revokeSessions(ctx, userID, "roles_changed")
+	})
+}
+
+func (s *service) DeleteUser(ctx context.Context, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.DeleteUser")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Deleting user by admin", "userID", userID)
+	defer func() { s.recordAdminAction(ctx, "delete_user", userID, err, nil) }()
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return s.revokeSessions(ctx, userID, "user_deleted")
+	})
+}
+
+func temporaryPassword() (string, error) {
+	b := make([]byte, tempPasswordBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *service) recordAdminAction(ctx context.Context, action, userID string, err error, details map[string]any) {
+	if details == nil {
+		details = map[string]any{}
+	}
+	details["action"] = action
+	s.recordEvent(ctx, models.AuditAdminAction, userID, err, details)
+}
+
+func (s *service) updateUser(ctx context.Context, userID string, changes models.UserChanges) error {
+	changes.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateUser(ctx, userID, changes); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
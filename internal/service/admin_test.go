@@ -0,0 +1,53 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListUsers_Pagination(t *testing.T) {
+	tests := []struct {
+		name        string
+		page, limit int
+		listed      [2]int64
+	}{
+		{"first page", 1, 10, [2]int64{0, 10}},
+		{"third page", 3, 10, [2]int64{20, 10}},
+		{"zero page", 0, 10, [2]int64{0, 10}},
+		{"negative page", -5, 10, [2]int64{0, 10}},
+		{"default limit", 2, 0, [2]int64{20, 20}},
+		{"negative limit", 1, -1, [2]int64{0, 20}},
+		{"limit above max", 2, 1000, [2]int64{100, 100}},
+		{"huge page", math.MaxInt, 100, [2]int64{math.MaxInt64, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRepo{}
+			s := service.New(r, nil, nil, config.Login{}, config.PasswordPolicy{}, config.AccountDeletion{})
+
+			_, _, err := s.ListUsers(context.Background(), models.UserFilter{}, tt.page, tt.limit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.listed, r.listed)
+		})
+	}
+}
+
+func TestSetUserStatus_RollsBackWhenRevocationFails(t *testing.T) {
+	r := &fakeRepo{revokeErr: errors.New("connection reset")}
+	s := service.New(r, nil, nil, config.Login{}, config.PasswordPolicy{}, config.AccountDeletion{})
+
+	err := s.SetUserStatus(context.Background(), "1", models.StatusDisabled)
+	assert.ErrorIs(t, err, r.revokeErr)
+	assert.Empty(t, r.changes)
+
+	err = s.SetUserRoles(context.Background(), "1", []string{"admin"})
+	assert.ErrorIs(t, err, r.revokeErr)
+	assert.Empty(t, r.changes)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
+	"github.com/avran02/authentication/internal/tracing"
+	"github.com/google/uuid"
+)
+
+// recordEvent appends an event to the audit trail. The outcome is derived from err.
+// Failing to write the event is logged and does not fail the audited operation.
+func (s *service) recordEvent(ctx context.Context, eventType models.AuditEventType, userID string, err error, details map[string]any) {
+	info := requestinfo.FromContext(ctx)
+	event := models.AuditEvent{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		UserID:    userID,
+		ActorID:   info.ActorID,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Outcome:   models.AuditSuccess,
+		Details:   details,
+	}
+	if event.ActorID == "" {
+		event.ActorID = userID
+	}
+	if err != nil {
+		event.Outcome = models.AuditFailure
+		event.Reason = err.Error()
+	}
+
+	// the audit trail must be written even if the request was canceled
+	if err := s.repo.WriteAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		slog.Error("failed to write audit event", "type", eventType, "userID", userID, "error", err.Error())
+	}
+}
+
+func (s *service) ListAuditEvents(ctx context.Context, filter models.AuditFilter, page, limit int) (_ []models.AuditEvent, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListAuditEvents")
+	defer func() { tracing.End(span, err) }()
+	offset, size := pagination(page, limit)
+
+	events, total, err := s.repo.FindAuditEvents(ctx, filter, offset, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find audit events: %w", err)
+	}
+	return events, total, nil
+}
@@ -3,9 +3,25 @@ package service
 import "errors"
 
 var (
-	ErrUserNotFound      = errors.New("user already exists")
+	ErrUserNotFound      = errors.New("user not found")
 	ErrTokenDoesntExist  = errors.New("token doesn't exist")
 	ErrWrongCredentials  = errors.New("wrong credentials")
 	ErrWrongTokensPair   = errors.New("wrong tokens pair")
 	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrEmptyUsername     = errors.New("username is empty")
+	ErrInvalidUsername   = errors.New("username must be at most 64 characters long without @")
+	ErrInvalidPassword   = errors.New("password length is outside the password policy")
+	ErrInvalidEmail      = errors.New("email is invalid")
+	ErrInvalidAvatarURL  = errors.New("avatar url must be an absolute http(s) url")
+	ErrInvalidLocale     = errors.New("locale is not a valid BCP 47 language tag")
+	ErrAccountDeleted    = errors.New("account is deleted")
+	ErrAccountDisabled   = errors.New("account is disabled")
+	ErrAccountLocked     = errors.New("account is locked")
+	ErrAccountPending    = errors.New("account is pending verification")
+	ErrTooManyAttempts   = errors.New("too many login attempts, try again later")
+	ErrInvalidStatus     = errors.New("unknown account status")
+	ErrForbidden         = errors.New("admin role required")
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http(s) url")
+	ErrUnknownEvent      = errors.New("unknown webhook event")
+	ErrCursorExpired     = errors.New("revocations after the cursor are no longer retained")
 )
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/repo"
+)
+
+// fakeRepo keeps users, audit events and revocations in memory. Methods the tests don't use panic through the nil Repo.
+type fakeRepo struct {
+	repo.Repo
+
+	mu          sync.Mutex
+	users       []models.User
+	auditEvents []models.AuditEvent
+	listed      [2]int64 // offset and limit of the last ListUsers call
+	changes     []models.UserChanges
+	// revokeErr fails DeleteAllUserTokens
+	revokeErr     error
+	revocations   []models.Revocation
+	revocationSeq int64
+}
+
+func (r *fakeRepo) FindUserByUsername(_ context.Context, username string) (*models.User, error) {
+	return r.findUser(func(user models.User) bool { return user.Username == username })
+}
+
+func (r *fakeRepo) FindUserByEmail(_ context.Context, email string) (*models.User, error) {
+	return r.findUser(func(user models.User) bool { return user.Email != nil && *user.Email == email })
+}
+
+// WithTransaction drops the user changes made by fn if it fails, like a rolled back transaction.
+func (r *fakeRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.mu.Lock()
+	changes := len(r.changes)
+	r.mu.Unlock()
+
+	err := fn(ctx)
+	if err != nil {
+		r.mu.Lock()
+		r.changes = r.changes[:changes]
+		r.mu.Unlock()
+	}
+	return err
+}
+
+func (r *fakeRepo) DeleteAllUserTokens(context.Context, string) error {
+	return r.revokeErr
+}
+
+func (r *fakeRepo) FindUserByID(_ context.Context, userID string) (*models.User, error) {
+	return r.findUser(func(user models.User) bool { return user.ID == userID })
+}
+
+func (r *fakeRepo) UpdateUser(_ context.Context, _ string, changes models.UserChanges) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, changes)
+	return nil
+}
+
+func (r *fakeRepo) findUser(match func(models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, repo.ErrUserNotFound
+}
+
+func (r *fakeRepo) ListUsers(_ context.Context, _ models.UserFilter, offset, limit int64) ([]models.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listed = [2]int64{offset, limit}
+	return nil, int64(len(r.users)), nil
+}
+
+func (r *fakeRepo) WriteAuditEvent(_ context.Context, event models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.auditEvents = append(r.auditEvents, event)
+	return nil
+}
+
+func (r *fakeRepo) FindRevocations(_ context.Context, afterSeq int64, limit int64) ([]models.Revocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []models.Revocation
+	for _, revocation := range r.revocations {
+		if revocation.Seq > afterSeq && int64(len(found)) < limit {
+			found = append(found, revocation)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepo) RevocationSeqRange(context.Context) (oldest, latest int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.revocations) > 0 {
+		oldest = r.revocations[0].Seq
+	}
+	return oldest, r.revocationSeq, nil
+}
+
+// revoke adds revocations with the given seqs, seqs left out have expired or were never written.
+func (r *fakeRepo) revoke(seqs ...int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, seq := range seqs {
+		r.revocations = append(r.revocations, models.Revocation{Seq: seq, UserID: "user123", Reason: "logout"})
+		r.revocationSeq = max(r.revocationSeq, seq)
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/avran02/authentication/internal/metrics"
+	"github.com/avran02/authentication/internal/pkg/jwt"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// failureReasons are the reason labels of failed operations. Other errors are counted as "error".
+var failureReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrWrongCredentials, "wrong_credentials"},
+	{repo.ErrUserNotFound, "user_not_found"},
+	{ErrUserAlreadyExists, "user_already_exists"},
+	{ErrAccountDeleted, "account_deleted"},
+	{ErrAccountDisabled, "account_disabled"},
+	{ErrAccountLocked, "account_locked"},
+	{ErrAccountPending, "account_pending_verification"},
+	{ErrTooManyAttempts, "rate_limited"},
+	{jwt.ErrExpiredToken, "token_expired"},
+	{jwt.ErrEmptyToken, "token_invalid"},
+	{jwt.ErrInvalidToken, "token_invalid"},
+	{ErrTokenDoesntExist, "token_revoked"},
+	{ErrWrongTokensPair, "token_revoked"},
+	{repo.ErrTokenNotFound, "token_revoked"},
+}
+
+// countOutcome increments counter with the outcome and failure reason of err.
+func countOutcome(counter *prometheus.CounterVec, err error) {
+	reason := ""
+	if err != nil {
+		reason = "error"
+		for _, r := range failureReasons {
+			if errors.Is(err, r.err) {
+				reason = r.reason
+				break
+			}
+		}
+	}
+	counter.WithLabelValues(metrics.Outcome(err), reason).Inc()
+}
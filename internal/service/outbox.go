@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/google/uuid"
+)
+
+// writeEvent adds an event to the outbox. Call it in the transaction of the state change the event is about.
+func (s *service) writeEvent(ctx context.Context, eventType models.EventType, userID string, data map[string]any) error {
+	err := s.repo.WriteOutboxEvent(ctx, models.Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		UserID:    userID,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+	return nil
+}
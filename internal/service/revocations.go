@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/tracing"
+)
+
+const (
+	revocationPollInterval = time.Second
+	revocationBatchSize    = 100
+	// revocationGapTimeout is how long the feed waits for a missing seq to show up. Seqs go missing
+	// when a revocation fails after its seq was taken on a server without transactions.
+	// Seqs that expired are not waited for, the cursor expired then.
+	revocationGapTimeout = 5 * time.Second
+)
+
+// WatchRevocations calls send for every revocation after cursor in seq order until ctx is done or send fails.
+func (s *service) WatchRevocations(ctx context.Context, cursor int64, send func(revocation models.Revocation) error) (err error) {
+	ctx, span := tracing.Start(ctx, "service.WatchRevocations")
+	defer func() { tracing.End(span, err) }()
+	if cursor > 0 {
+		if err = s.checkCursor(ctx, cursor); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(revocationPollInterval)
+	defer ticker.Stop()
+
+	var gapSince time.Time
+	for {
+		revocations, err := s.repo.FindRevocations(ctx, cursor, revocationBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to find revocations: %w", err)
+		}
+
+		waiting := false
+		for _, revocation := range revocations {
+			if cursor > 0 && revocation.Seq != cursor+1 {
+				if err = s.checkCursor(ctx, cursor); err != nil {
+					return err
+				}
+				if gapSince.IsZero() {
+					gapSince = time.Now()
+				}
+				if time.Since(gapSince) < revocationGapTimeout {
+					waiting = true
+					break
+				}
+				slog.Warn("skipping missing revocations", "from", cursor+1, "to", revocation.Seq-1)
+			}
+			gapSince = time.Time{}
+
+			if err = send(revocation); err != nil {
+				return err
+			}
+			cursor = revocation.Seq
+		}
+
+		if len(revocations) == revocationBatchSize && !waiting {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkCursor fails with ErrCursorExpired if revocations after cursor were assigned but are no longer retained.
+// A cursor after the last assigned seq, e.g. of another database, is expired too.
+func (s *service) checkCursor(ctx context.Context, cursor int64) error {
+	oldest, latest, err := s.repo.RevocationSeqRange(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find retained revocations: %w", err)
+	}
+	if cursor > latest || (cursor < latest && (oldest == 0 || oldest > cursor+1)) {
+		return ErrCursorExpired
+	}
+	return nil
+}
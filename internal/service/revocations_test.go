@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func watch(t *testing.T, r *fakeRepo, cursor int64) ([]int64, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var seqs []int64
+	err := service.New(r, nil, nil, config.Login{}, config.PasswordPolicy{}, config.AccountDeletion{}).
+		WatchRevocations(ctx, cursor, func(revocation models.Revocation) error {
+			seqs = append(seqs, revocation.Seq)
+			return nil
+		})
+	return seqs, err
+}
+
+func TestWatchRevocations(t *testing.T) {
+	r := &fakeRepo{}
+	r.revoke(3, 4, 5)
+
+	seqs, err := watch(t, r, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4, 5}, seqs)
+
+	seqs, err = watch(t, r, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4, 5}, seqs)
+
+	seqs, err = watch(t, r, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, seqs)
+}
+
+func TestWatchRevocations_CursorExpired(t *testing.T) {
+	for name, tc := range map[string]struct {
+		retained []int64
+		cursor   int64
+	}{
+		"older than retained":  {retained: []int64{8, 9, 10}, cursor: 3},
+		"all expired":          {cursor: 3},
+		"after the latest seq": {retained: []int64{1, 2}, cursor: 7},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &fakeRepo{revocationSeq: 10}
+			r.revoke(tc.retained...)
+			if tc.retained != nil {
+				r.revocationSeq = tc.retained[len(tc.retained)-1]
+			}
+
+			_, err := watch(t, r, tc.cursor)
+			assert.ErrorIs(t, err, service.ErrCursorExpired)
+		})
+	}
+}
+
+func TestWatchRevocations_AllExpiredAfterCatchingUp(t *testing.T) {
+	r := &fakeRepo{revocationSeq: 10}
+
+	seqs, err := watch(t, r, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, seqs)
+}
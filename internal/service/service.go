@@ -7,13 +7,24 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/metrics"
 	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/hasher"
 	"github.com/avran02/authentication/internal/pkg/jwt"
+	"github.com/avran02/authentication/internal/pkg/ratelimit"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
 	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/internal/tracing"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/language"
 )
 
 type Service interface {
@@ -22,15 +33,45 @@ type Service interface {
 		username, password string,
 		email *string,
 	) (id, accessToken, refreshToken string, expTime time.Time, err error)
-	Login(ctx context.Context, username, password string) (id, accessToken, refreshToken string, expTime time.Time, err error)
+	// Login authenticates a user by username or email, depending on the allowed login identifiers.
+	Login(ctx context.Context, login, password string) (id, accessToken, refreshToken string, expTime time.Time, err error)
 	RefreshTokens(ctx context.Context, token string) (accessToken, refreshToken string, expTime time.Time, err error)
 	ValidateToken(ctx context.Context, token string) (string, error)
+	// Authenticate validates the access token like ValidateToken and returns all of its claims.
+	Authenticate(ctx context.Context, token string) (models.AccessTokenClaims, error)
 	Logout(ctx context.Context, token string) (bool, error)
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	// UpdateUser applies a profile update. Changing the email marks it as unverified.
+	UpdateUser(ctx context.Context, userID string, update models.UserUpdate) (*models.User, error)
+	// DeleteAccount soft deletes the user after the password is confirmed and logs out all sessions.
+	DeleteAccount(ctx context.Context, userID, password string) (purgeAt time.Time, err error)
+	ExportUserData(ctx context.Context, userID string) (*models.UserExport, error)
+	// PurgeDeletedUsers removes users whose deletion grace period is over.
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
+	// WatchRevocations calls send for every session revocation after cursor, including new ones
+	// as they happen, until ctx is done or send fails. It fails with ErrCursorExpired if revocations
+	// after cursor are no longer retained.
+	WatchRevocations(ctx context.Context, cursor int64, send func(revocation models.Revocation) error) error
+	// Reload applies the login settings, including the attempt limit, and the password policy
+	// to the requests received from now on.
+	Reload(loginConfig config.Login, passwordPolicy config.PasswordPolicy)
+	AdminService
 }
 
 type service struct {
-	repo repo.Repo
-	jwt  jwt.Generator
+	repo           repo.Repo
+	jwt            jwt.Generator
+	hasher         hasher.Hasher
+	deletionConfig config.AccountDeletion
+	// settings are swapped as a whole on Reload.
+	settings atomic.Pointer[settings]
+	// loginLimiter limits the login attempts per client IP.
+	loginLimiter *ratelimit.Limiter
+}
+
+type settings struct {
+	login          config.Login
+	passwordPolicy config.PasswordPolicy
 }
 
 func (s *service) Register(
@@ -38,76 +79,126 @@ func (s *service) Register(
 	username, password string,
 	email *string,
 ) (id, accessToken, refreshToken string, expTime time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "service.Register")
+	defer func() { tracing.End(span, err) }()
 	slog.Info("Registering user: " + username)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", "", "", time.Time{}, fmt.Errorf("failed to hash password: %w", err)
+	defer func() { s.recordEvent(ctx, models.AuditRegister, id, err, map[string]any{"username": username}) }()
+	defer func() { countOutcome(metrics.Registrations, err) }()
+	if err = s.validatePassword(password); err != nil {
+		return "", "", "", time.Time{}, err
 	}
-
-	user, err := s.repo.FindUserByUsername(ctx, username)
-	if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
-		return "", "", "", time.Time{}, fmt.Errorf("failed to find user: %w", err)
+	if err = s.ensureUsernameAvailable(ctx, username); err != nil {
+		return "", "", "", time.Time{}, err
 	}
-	if user != nil {
-		return "", "", "", time.Time{}, ErrUserAlreadyExists
+	if email != nil {
+		if err = s.ensureEmailAvailable(ctx, *email); err != nil {
+			return "", "", "", time.Time{}, err
+		}
 	}
 
-	id = uuid.NewString()
-	if err = s.repo.CreateUser(ctx, models.User{
-		ID:       id,
-		Email:    email,
-		Username: username,
-		Password: string(hashedPassword),
-	}); err != nil {
-		return "", "", "", time.Time{}, fmt.Errorf("failed ti create user: %w", err)
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", "", "", time.Time{}, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	id = uuid.NewString()
 	// todo: refactor duplicates part of login
-	accessToken, accessTokenID, refreshToken, expTime, err := s.jwt.Generate(id)
+	accessToken, accessTokenID, refreshToken, expTime, err := s.generateTokens(ctx, id, nil)
 	if err != nil {
 		return "", "", "", time.Time{}, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	if err = s.saveRefreshToken(ctx, id, accessTokenID, []byte(refreshToken)); err != nil {
-		return "", "", "", time.Time{}, fmt.Errorf("failed to save refresh token: %w", err)
+	now := time.Now().UTC()
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateUser(ctx, models.User{
+			ID:        id,
+			Email:     email,
+			Username:  username,
+			Password:  hashedPassword,
+			Status:    models.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}); err != nil {
+			return fmt.Errorf("failed ti create user: %w", err)
+		}
+		if err := s.saveRefreshToken(ctx, id, accessTokenID, []byte(refreshToken)); err != nil {
+			return fmt.Errorf("failed to save refresh token: %w", err)
+		}
+		return s.writeEvent(ctx, models.EventUserRegistered, id, map[string]any{"username": username})
+	})
+	if err != nil {
+		return "", "", "", time.Time{}, err
 	}
 
 	return id, accessToken, refreshToken, expTime, nil
 }
 
-func (s *service) Login(ctx context.Context, username, password string) (id, accessToken, refreshToken string, expTime time.Time, err error) {
-	slog.Info("Logging in user: " + username)
-	user, err := s.repo.FindUserByUsername(ctx, username)
+func (s *service) Login(ctx context.Context, login, password string) (id, accessToken, refreshToken string, expTime time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "service.Login")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Logging in user: " + login)
+	var userID string
+	defer func() { s.recordEvent(ctx, models.AuditLogin, userID, err, map[string]any{"login": login}) }()
+	defer func() { countOutcome(metrics.Logins, err) }()
+
+	if ip := requestinfo.FromContext(ctx).IP; ip != "" && !s.loginLimiter.Allow(ip) {
+		return "", "", "", time.Time{}, ErrTooManyAttempts
+	}
+	user, err := s.findUserByLogin(ctx, login)
 	if err != nil {
 		return "", "", "", time.Time{}, fmt.Errorf("failed to find user: %w", err)
 	}
+	userID = user.ID
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	ok, needsRehash, err := s.hasher.Verify(password, user.Password)
+	if err != nil {
+		slog.Error("failed to verify password", "userID", user.ID, "error", err.Error())
 		return "", "", "", time.Time{}, ErrWrongCredentials
 	}
-
-	if err = s.repo.DeleteAllUserTokens(ctx, user.ID); err != nil {
-		return "", "", "", time.Time{}, fmt.Errorf("failed to delete all user tokens: %w", err)
+	if !ok {
+		return "", "", "", time.Time{}, ErrWrongCredentials
+	}
+	if err = checkAccountStatus(user); err != nil {
+		return "", "", "", time.Time{}, err
+	}
+	if needsRehash {
+		s.rehashPassword(ctx, user.ID, password)
 	}
 
-	accessToken, accessTokenID, refreshToken, expTime, err := s.jwt.Generate(user.ID)
+	accessToken, accessTokenID, refreshToken, expTime, err := s.generateTokens(ctx, user.ID, user.Roles)
 	if err != nil {
 		return "", "", "", time.Time{}, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	if err = s.saveRefreshToken(ctx, user.ID, accessTokenID, []byte(refreshToken)); err != nil {
-		return "", "", "", time.Time{}, fmt.Errorf("failed to save refresh token: %w", err)
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteAllUserTokens(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to delete all user tokens: %w", err)
+		}
+		if err := s.saveRefreshToken(ctx, user.ID, accessTokenID, []byte(refreshToken)); err != nil {
+			return fmt.Errorf("failed to save refresh token: %w", err)
+		}
+		return s.writeEvent(ctx, models.EventUserLoggedIn, user.ID, nil)
+	})
+	if err != nil {
+		return "", "", "", time.Time{}, err
 	}
 
 	return user.ID, accessToken, refreshToken, expTime, nil
 }
 
 func (s *service) RefreshTokens(ctx context.Context, refreshTokenStr string) (newAccessToken, newRefreshToken string, expTime time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "service.RefreshTokens")
+	defer func() { tracing.End(span, err) }()
 	slog.Info("authenticationService.RefreshTokens")
-	refreshToken, err := s.jwt.ParseRefreshToken(refreshTokenStr)
+	var userID string
+	defer func() { s.recordEvent(ctx, models.AuditRefresh, userID, err, nil) }()
+	defer func() { countOutcome(metrics.TokenRefreshes, err) }()
+
+	refreshToken, err := s.parseRefreshToken(ctx, refreshTokenStr)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: can't validate refresh token: %w", err)
 	}
+	userID = refreshToken.Subject
 
 	writtenRefreshTokenHash, writtenAccessTokenID, err := s.repo.GetRefreshTokenInfo(ctx, refreshToken.Subject)
 	slog.Debug("authenticationService.RefreshTokens", "writtenRefreshTokenHash", writtenRefreshTokenHash, "writtenAccessTokenID", writtenAccessTokenID)
@@ -126,44 +217,106 @@ func (s *service) RefreshTokens(ctx context.Context, refreshTokenStr string) (ne
 		return "", "", time.Time{}, fmt.Errorf("wrong access token id: %w", ErrWrongTokensPair)
 	}
 
-	newAccessToken, newAccessTokenID, newRefreshToken, expTime, err := s.jwt.Generate(refreshToken.Subject)
+	user, err := s.repo.FindUserByID(ctx, refreshToken.Subject)
 	if err != nil {
-		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: can't generate new tokens: %w", err)
+		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: can't find user: %w", err)
+	}
+	if err = checkAccountStatus(user); err != nil {
+		return "", "", time.Time{}, err
 	}
 
-	if err = s.repo.DeleteAllUserTokens(ctx, refreshToken.Subject); err != nil {
-		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: can't delete all user tokens: %w", err)
+	newAccessToken, newAccessTokenID, newRefreshToken, expTime, err := s.generateTokens(ctx, refreshToken.Subject, user.Roles)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: can't generate new tokens: %w", err)
 	}
 
-	if err = s.saveRefreshToken(ctx, refreshToken.Subject, newAccessTokenID, []byte(newRefreshToken)); err != nil {
-		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: can't save new refresh token: %w", err)
+	// the old session must survive a failed save
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteAllUserTokens(ctx, refreshToken.Subject); err != nil {
+			return fmt.Errorf("can't delete all user tokens: %w", err)
+		}
+		if err := s.saveRefreshToken(ctx, refreshToken.Subject, newAccessTokenID, []byte(newRefreshToken)); err != nil {
+			return fmt.Errorf("can't save new refresh token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("authenticationService.RefreshTokens: %w", err)
 	}
 
 	return newAccessToken, newRefreshToken, expTime, nil
 }
 
-func (s *service) ValidateToken(ctx context.Context, token string) (string, error) {
-	claims, err := s.jwt.ParseAccessToken(token)
+func (s *service) ValidateToken(ctx context.Context, token string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "service.ValidateToken")
+	defer func() { tracing.End(span, err) }()
+	defer metrics.Since(metrics.TokenValidationDuration, time.Now())
+	defer func() { countOutcome(metrics.TokenValidations, err) }()
+
+	claims, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+func (s *service) Authenticate(ctx context.Context, token string) (_ models.AccessTokenClaims, err error) {
+	ctx, span := tracing.Start(ctx, "service.Authenticate")
+	defer func() { tracing.End(span, err) }()
+	claims, err := s.parseAccessToken(ctx, token)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse access token: %w", err)
+		return models.AccessTokenClaims{}, fmt.Errorf("failed to parse access token: %w", err)
 	}
 
 	_, writtenAccessTokenID, err := s.repo.GetRefreshTokenInfo(ctx, claims.Subject)
 	if err != nil {
-		return "", fmt.Errorf("authenticationService.RefreshTokens: can't get refresh token info: %w", err)
+		return models.AccessTokenClaims{}, fmt.Errorf("authenticationService.RefreshTokens: can't get refresh token info: %w", err)
 	}
 	if writtenAccessTokenID != claims.ID {
-		return "", fmt.Errorf("wrong access token id: %w", ErrWrongTokensPair)
+		return models.AccessTokenClaims{}, fmt.Errorf("wrong access token id: %w", ErrWrongTokensPair)
 	}
 
-	return claims.Subject, nil
+	user, err := s.repo.FindUserByID(ctx, claims.Subject)
+	if err != nil {
+		return models.AccessTokenClaims{}, fmt.Errorf("failed to find user: %w", err)
+	}
+	if err = checkAccountStatus(user); err != nil {
+		return models.AccessTokenClaims{}, err
+	}
+
+	return claims, nil
 }
 
-func (s *service) Logout(ctx context.Context, token string) (bool, error) {
-	claims, err := s.jwt.ParseAccessToken(token)
+// checkAccountStatus returns the typed error for accounts that are not allowed to authenticate.
+func checkAccountStatus(user *models.User) error {
+	if user.DeletedAt != nil {
+		return ErrAccountDeleted
+	}
+
+	switch user.Status {
+	case models.StatusActive, "":
+		return nil
+	case models.StatusLocked:
+		return ErrAccountLocked
+	case models.StatusPendingVerification:
+		return ErrAccountPending
+	default:
+		return ErrAccountDisabled
+	}
+}
+
+func (s *service) Logout(ctx context.Context, token string) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "service.Logout")
+	defer func() { tracing.End(span, err) }()
+	var userID string
+	defer func() { s.recordEvent(ctx, models.AuditLogout, userID, err, nil) }()
+	defer func() { countOutcome(metrics.Logouts, err) }()
+
+	claims, err := s.parseAccessToken(ctx, token)
 	if err != nil {
 		return false, fmt.Errorf("failed to parse access token: %w", err)
 	}
+	userID = claims.Subject
 
 	_, writtenAccessTokenID, err := s.repo.GetRefreshTokenInfo(ctx, claims.Subject)
 	if err != nil {
@@ -173,23 +326,283 @@ func (s *service) Logout(ctx context.Context, token string) (bool, error) {
 		return false, fmt.Errorf("wrong access token id: %w", ErrWrongTokensPair)
 	}
 
-	err = s.repo.DeleteAllUserTokens(ctx, claims.Subject)
-	if err != nil {
+	if err = s.revokeSessions(ctx, claims.Subject, "logout"); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+func (s *service) GetUser(ctx context.Context, userID string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetUser")
+	defer func() { tracing.End(span, err) }()
+	user, err := s.repo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *service) UpdateUser(ctx context.Context, userID string, update models.UserUpdate) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "service.UpdateUser")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Updating user: " + userID)
+	defer func() { s.recordEvent(ctx, models.AuditProfileUpdate, userID, err, nil) }()
+	user, err := s.repo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	changes := models.UserChanges{UpdatedAt: time.Now().UTC()}
+	if update.Username != nil && *update.Username != user.Username {
+		if err = s.ensureUsernameAvailable(ctx, *update.Username); err != nil {
+			return nil, err
+		}
+		changes.Username = update.Username
+		user.Username = *update.Username
+	}
+
+	if update.Email != nil && (user.Email == nil || !strings.EqualFold(*update.Email, *user.Email)) {
+		if err = s.ensureEmailAvailable(ctx, *update.Email); err != nil {
+			return nil, err
+		}
+		emailVerified := false
+		changes.Email, changes.EmailVerified = update.Email, &emailVerified
+		user.Email, user.EmailVerified = update.Email, false
+	}
+
+	if update.DisplayName != nil {
+		changes.DisplayName = update.DisplayName
+		user.DisplayName = *update.DisplayName
+	}
+	if update.AvatarURL != nil {
+		if err = validateAvatarURL(*update.AvatarURL); err != nil {
+			return nil, err
+		}
+		changes.AvatarURL = update.AvatarURL
+		user.AvatarURL = *update.AvatarURL
+	}
+	if update.Locale != nil {
+		if err = validateLocale(*update.Locale); err != nil {
+			return nil, err
+		}
+		changes.Locale = update.Locale
+		user.Locale = *update.Locale
+	}
+	if update.Metadata != nil {
+		changes.Metadata = update.Metadata
+		user.Metadata = update.Metadata
+	}
+
+	user.UpdatedAt = changes.UpdatedAt
+	if err = s.repo.UpdateUser(ctx, userID, changes); err != nil {
+		if errors.Is(err, repo.ErrDuplicateUser) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *service) DeleteAccount(ctx context.Context, userID, password string) (_ time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "service.DeleteAccount")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Deleting user: " + userID)
+	defer func() { s.recordEvent(ctx, models.AuditAccountDelete, userID, err, nil) }()
+	user, err := s.repo.FindUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	ok, _, err := s.hasher.Verify(password, user.Password)
+	if err != nil || !ok {
+		return time.Time{}, ErrWrongCredentials
+	}
+
+	deletedAt := time.Now().UTC()
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.SoftDeleteUser(ctx, userID, deletedAt); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return s.revokeSessions(ctx, userID, "account_deleted")
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return deletedAt.Add(s.deletionConfig.GracePeriod), nil
+}
+
+func (s *service) ExportUserData(ctx context.Context, userID string) (_ *models.UserExport, err error) {
+	ctx, span := tracing.Start(ctx, "service.ExportUserData")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Exporting user data: " + userID)
+	user, err := s.repo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	sessions, err := s.repo.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	events, _, err := s.repo.FindAuditEvents(ctx, models.AuditFilter{UserID: userID}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user audit events: %w", err)
+	}
+
+	return &models.UserExport{
+		User:        *user,
+		Sessions:    sessions,
+		AuditEvents: events,
+	}, nil
+}
+
+func (s *service) PurgeDeletedUsers(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "service.PurgeDeletedUsers")
+	defer func() { tracing.End(span, err) }()
+	purged, err := s.repo.PurgeDeletedUsers(ctx, time.Now().UTC().Add(-s.deletionConfig.GracePeriod))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	return purged, nil
+}
+
+// The limits of the user fields, the HTTP DTOs check the same ones.
+const (
+	maxUsernameLength = 64
+	maxEmailLength    = 254
+)
+
+func (s *service) validatePassword(password string) error {
+	policy := s.settings.Load().passwordPolicy
+	if n := utf8.RuneCountInString(password); n < policy.MinLength || n > policy.MaxLength {
+		return fmt.Errorf("%w: it must be %d to %d characters long", ErrInvalidPassword, policy.MinLength, policy.MaxLength)
+	}
+	// bcrypt can't hash longer passwords
+	if limit := s.hasher.MaxPasswordLength(); limit > 0 && len(password) > limit {
+		return fmt.Errorf("%w: it must be at most %d bytes long", ErrInvalidPassword, limit)
+	}
+	return nil
+}
+
+func (s *service) ensureUsernameAvailable(ctx context.Context, username string) error {
+	if strings.TrimSpace(username) == "" {
+		return ErrEmptyUsername
+	}
+	// logins with @ are looked up as emails first
+	if utf8.RuneCountInString(username) > maxUsernameLength || strings.Contains(username, "@") {
+		return ErrInvalidUsername
+	}
+
+	user, err := s.repo.FindUserByUsername(ctx, username)
+	if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user != nil {
+		return ErrUserAlreadyExists
+	}
+	return nil
+}
+
+func (s *service) ensureEmailAvailable(ctx context.Context, email string) error {
+	if len(email) > maxEmailLength {
+		return ErrInvalidEmail
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return ErrInvalidEmail
+	}
+
+	user, err := s.repo.FindUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user != nil {
+		return ErrUserAlreadyExists
+	}
+	return nil
+}
+
+func validateAvatarURL(avatarURL string) error {
+	if avatarURL == "" {
+		return nil
+	}
+	u, err := url.Parse(avatarURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return ErrInvalidAvatarURL
+	}
+	return nil
+}
+
+func validateLocale(locale string) error {
+	if locale == "" {
+		return nil
+	}
+	if _, err := language.Parse(locale); err != nil {
+		return ErrInvalidLocale
+	}
+	return nil
+}
+
+// findUserByLogin treats logins containing "@" as emails when email logins are allowed. New usernames
+// can't contain "@", older and imported ones are still found by username if no email matches.
+func (s *service) findUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	loginConfig := s.settings.Load().login
+	if strings.Contains(login, "@") && loginConfig.Allows("email") {
+		user, err := s.repo.FindUserByEmail(ctx, login)
+		if !errors.Is(err, repo.ErrUserNotFound) || !loginConfig.Allows("username") {
+			return user, err
+		}
+	}
+	if loginConfig.Allows("username") {
+		return s.repo.FindUserByUsername(ctx, login)
+	}
+	return nil, repo.ErrUserNotFound
+}
+
+// rehashPassword upgrades an outdated password hash. Failures are only logged so that the login still succeeds.
+func (s *service) rehashPassword(ctx context.Context, userID, password string) {
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		slog.Warn("failed to rehash password", "userID", userID, "error", err.Error())
+		return
+	}
+
+	if err = s.repo.UpdateUserPassword(ctx, userID, hashedPassword); err != nil {
+		slog.Warn("failed to save rehashed password", "userID", userID, "error", err.Error())
+		return
+	}
+	slog.Info("password rehashed", "userID", userID)
+}
+
 func (s *service) saveRefreshToken(ctx context.Context, userID, accessTokenID string, refreshToken []byte) error {
 	hasedRefreshToken := sha256.New().Sum(refreshToken)
 	encodedRefreshToken := base64.RawStdEncoding.EncodeToString(hasedRefreshToken)
 	return s.repo.WriteRefreshToken(ctx, userID, accessTokenID, encodedRefreshToken)
 }
 
-func New(repo repo.Repo, jwt jwt.Generator) Service {
-	return &service{
-		repo: repo,
-		jwt:  jwt,
+func New(
+	repo repo.Repo,
+	jwt jwt.Generator,
+	hasher hasher.Hasher,
+	loginConfig config.Login,
+	passwordPolicy config.PasswordPolicy,
+	deletionConfig config.AccountDeletion,
+) Service {
+	s := &service{
+		repo:           repo,
+		jwt:            jwt,
+		hasher:         hasher,
+		deletionConfig: deletionConfig,
+		loginLimiter:   ratelimit.New(loginConfig.MaxAttempts, loginConfig.AttemptsPeriod),
 	}
+	s.settings.Store(&settings{login: loginConfig, passwordPolicy: passwordPolicy})
+	return s
+}
+
+func (s *service) Reload(loginConfig config.Login, passwordPolicy config.PasswordPolicy) {
+	s.settings.Store(&settings{login: loginConfig, passwordPolicy: passwordPolicy})
+	s.loginLimiter.Reload(loginConfig.MaxAttempts, loginConfig.AttemptsPeriod)
 }
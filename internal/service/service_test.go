@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/pkg/hasher"
+	"github.com/avran02/authentication/internal/pkg/requestinfo"
+	"github.com/avran02/authentication/internal/repo"
+	"github.com/avran02/authentication/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister_Validation(t *testing.T) {
+	taken := "taken@example.com"
+	email := func(s string) *string { return &s }
+	tests := []struct {
+		name     string
+		username string
+		password string
+		email    *string
+		err      error
+	}{
+		{"empty username", " ", "password123", nil, service.ErrEmptyUsername},
+		{"long username", strings.Repeat("a", 65), "password123", nil, service.ErrInvalidUsername},
+		{"username with @", "alice@example.com", "password123", nil, service.ErrInvalidUsername},
+		{"empty password", "alice", "", nil, service.ErrInvalidPassword},
+		{"short password", "alice", "passwor", nil, service.ErrInvalidPassword},
+		{"long password", "alice", strings.Repeat("a", 129), nil, service.ErrInvalidPassword},
+		{"password over the bcrypt limit", "alice", strings.Repeat("пароль", 7), nil, service.ErrInvalidPassword},
+		{"malformed email", "alice", "password123", email("alice"), service.ErrInvalidEmail},
+		{"email with name", "alice", "password123", email("Alice <alice@example.com>"), service.ErrInvalidEmail},
+		{"long email", "alice", "password123", email(strings.Repeat("a", 250) + "@example.com"), service.ErrInvalidEmail},
+		{"taken username", "bob", "password123", nil, service.ErrUserAlreadyExists},
+		{"taken email", "alice", "password123", &taken, service.ErrUserAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRepo{users: []models.User{{ID: "1", Username: "bob", Email: &taken}}}
+			h := hasher.New(config.Hasher{Algorithm: "bcrypt", Bcrypt: config.Bcrypt{Cost: 4}})
+			s := service.New(r, nil, h, config.Login{}, config.PasswordPolicy{MinLength: 8, MaxLength: 128}, config.AccountDeletion{})
+
+			_, _, _, _, err := s.Register(context.Background(), tt.username, tt.password, tt.email)
+			assert.ErrorIs(t, err, tt.err)
+			if assert.Len(t, r.auditEvents, 1) {
+				assert.Equal(t, models.AuditFailure, r.auditEvents[0].Outcome)
+			}
+		})
+	}
+}
+
+func TestReload_SwapsPasswordPolicy(t *testing.T) {
+	r := &fakeRepo{users: []models.User{{ID: "1", Username: "bob"}}}
+	s := service.New(r, nil, nil, config.Login{}, config.PasswordPolicy{MinLength: 8, MaxLength: 128}, config.AccountDeletion{})
+
+	s.Reload(config.Login{}, config.PasswordPolicy{MinLength: 12, MaxLength: 128})
+	_, _, _, _, err := s.Register(context.Background(), "alice", "password123", nil)
+	assert.ErrorIs(t, err, service.ErrInvalidPassword)
+	assert.ErrorContains(t, err, "12 to 128")
+}
+
+func TestUpdateUser_SetsOnlyChangedFields(t *testing.T) {
+	email := "bob@example.com"
+	r := &fakeRepo{users: []models.User{{ID: "1", Username: "bob", Email: &email, EmailVerified: true, Password: "hash"}}}
+	s := service.New(r, nil, nil, config.Login{}, config.PasswordPolicy{}, config.AccountDeletion{})
+
+	displayName, sameEmail := "Bob", "BOB@example.com"
+	user, err := s.UpdateUser(context.Background(), "1", models.UserUpdate{DisplayName: &displayName, Email: &sameEmail})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", user.DisplayName)
+	assert.True(t, user.EmailVerified)
+
+	if assert.Len(t, r.changes, 1) {
+		changes := r.changes[0]
+		assert.Equal(t, &displayName, changes.DisplayName)
+		assert.False(t, changes.UpdatedAt.IsZero())
+		changes.DisplayName, changes.UpdatedAt = nil, time.Time{}
+		assert.Zero(t, changes)
+	}
+}
+
+func TestLogin_Identifiers(t *testing.T) {
+	email := "alice@example.com"
+	both := []string{"username", "email"}
+	tests := []struct {
+		name        string
+		identifiers []string
+		login       string
+		userID      string // the user the login was looked up as, empty if none
+	}{
+		{"username", both, "alice", "1"},
+		{"email", both, email, "1"},
+		{"username with @ without matching email", both, "bob@legacy", "2"},
+		{"unknown email", both, "nobody@example.com", ""},
+		{"username with emails only", []string{"email"}, "alice", ""},
+		{"username with @ with emails only", []string{"email"}, "bob@legacy", ""},
+		{"email with emails only", []string{"email"}, email, "1"},
+		{"email with usernames only", []string{"username"}, email, ""},
+		{"username with @ with usernames only", []string{"username"}, "bob@legacy", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// imported usernames may contain @, new ones can't
+			r := &fakeRepo{users: []models.User{
+				{ID: "1", Username: "alice", Email: &email},
+				{ID: "2", Username: "bob@legacy"},
+			}}
+			h := hasher.New(config.Hasher{Algorithm: "bcrypt", Bcrypt: config.Bcrypt{Cost: 4}})
+			s := service.New(r, nil, h, config.Login{Identifiers: tt.identifiers}, config.PasswordPolicy{}, config.AccountDeletion{})
+
+			// the password is wrong, the audit event tells which user the login was looked up as
+			_, _, _, _, err := s.Login(context.Background(), tt.login, "wrong-password")
+			if tt.userID == "" {
+				assert.ErrorIs(t, err, repo.ErrUserNotFound)
+			} else {
+				assert.ErrorIs(t, err, service.ErrWrongCredentials)
+			}
+			if assert.Len(t, r.auditEvents, 1) {
+				assert.Equal(t, tt.userID, r.auditEvents[0].UserID)
+			}
+		})
+	}
+}
+
+func TestLogin_LimitsAttemptsPerIP(t *testing.T) {
+	r := &fakeRepo{users: []models.User{{ID: "1", Username: "alice"}}}
+	h := hasher.New(config.Hasher{Algorithm: "bcrypt", Bcrypt: config.Bcrypt{Cost: 4}})
+	s := service.New(r, nil, h, config.Login{Identifiers: []string{"username"}, MaxAttempts: 2, AttemptsPeriod: time.Minute},
+		config.PasswordPolicy{}, config.AccountDeletion{})
+	client := requestinfo.WithInfo(context.Background(), requestinfo.Info{IP: "10.0.0.1"})
+
+	for range 2 {
+		_, _, _, _, err := s.Login(client, "alice", "wrong-password")
+		assert.ErrorIs(t, err, service.ErrWrongCredentials)
+	}
+	_, _, _, _, err := s.Login(client, "alice", "wrong-password")
+	assert.ErrorIs(t, err, service.ErrTooManyAttempts)
+
+	other := requestinfo.WithInfo(context.Background(), requestinfo.Info{IP: "10.0.0.2"})
+	_, _, _, _, err = s.Login(other, "alice", "wrong-password")
+	assert.ErrorIs(t, err, service.ErrWrongCredentials)
+}
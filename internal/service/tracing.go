@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/tracing"
+)
+
+// The jwt.Generator doesn't take a context, these wrappers trace its calls.
+
+func (s *service) generateTokens(
+	ctx context.Context,
+	userID string,
+	roles []string,
+) (accessToken, accessTokenID, refreshToken string, expTime time.Time, err error) {
+	_, span := tracing.Start(ctx, "jwt.Generate")
+	defer func() { tracing.End(span, err) }()
+	return s.jwt.Generate(userID, roles)
+}
+
+func (s *service) parseAccessToken(ctx context.Context, token string) (_ models.AccessTokenClaims, err error) {
+	_, span := tracing.Start(ctx, "jwt.ParseAccessToken")
+	defer func() { tracing.End(span, err) }()
+	return s.jwt.ParseAccessToken(token)
+}
+
+func (s *service) parseRefreshToken(ctx context.Context, token string) (_ models.RefreshTokenClaims, err error) {
+	_, span := tracing.Start(ctx, "jwt.ParseRefreshToken")
+	defer func() { tracing.End(span, err) }()
+	return s.jwt.ParseRefreshToken(token)
+}
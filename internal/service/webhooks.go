@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const webhookSecretBytes = 32
+
+func (s *service) CreateWebhookEndpoint(
+	ctx context.Context,
+	endpointURL, secret string,
+	events []models.EventType,
+) (_ *models.WebhookEndpoint, err error) {
+	ctx, span := tracing.Start(ctx, "service.CreateWebhookEndpoint")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Creating webhook endpoint", "url", endpointURL)
+	defer func() { s.recordAdminAction(ctx, "create_webhook", "", err, map[string]any{"url": endpointURL}) }()
+
+	u, err := url.Parse(endpointURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+		}
+	}
+	if secret == "" {
+		b := make([]byte, webhookSecretBytes)
+		if _, err = rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	endpoint := models.WebhookEndpoint{
+		ID:        uuid.NewString(),
+		URL:       endpointURL,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err = s.repo.CreateWebhookEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return &endpoint, nil
+}
+
+func (s *service) ListWebhookEndpoints(ctx context.Context) (_ []models.WebhookEndpoint, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListWebhookEndpoints")
+	defer func() { tracing.End(span, err) }()
+	endpoints, err := s.repo.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (s *service) DeleteWebhookEndpoint(ctx context.Context, endpointID string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.DeleteWebhookEndpoint")
+	defer func() { tracing.End(span, err) }()
+	slog.Info("Deleting webhook endpoint", "id", endpointID)
+	defer func() { s.recordAdminAction(ctx, "delete_webhook", "", err, map[string]any{"endpointId": endpointID}) }()
+	if err = s.repo.DeleteWebhookEndpoint(ctx, endpointID); err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+func (s *service) ListWebhookDeadLetters(ctx context.Context, page, limit int) (_ []models.WebhookDeadLetter, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListWebhookDeadLetters")
+	defer func() { tracing.End(span, err) }()
+	offset, size := pagination(page, limit)
+
+	deadLetters, total, err := s.repo.FindWebhookDeadLetters(ctx, offset, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find webhook dead letters: %w", err)
+	}
+	return deadLetters, total, nil
+}
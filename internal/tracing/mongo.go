@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mongoTracer keeps the spans of running commands by request ID.
+type mongoTracer struct {
+	spans sync.Map
+}
+
+// MongoMonitor creates a client span for every MongoDB command.
+func MongoMonitor() *event.CommandMonitor {
+	t := &mongoTracer{}
+	return &event.CommandMonitor{
+		Started:   t.started,
+		Succeeded: t.succeeded,
+		Failed:    t.failed,
+	}
+}
+
+func (t *mongoTracer) started(ctx context.Context, e *event.CommandStartedEvent) {
+	// most commands name their collection as the value of the command
+	collection, _ := e.Command.Lookup(e.CommandName).StringValueOK()
+	_, span := Start(ctx, "mongo."+e.CommandName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMongoDB,
+			semconv.DBNamespace(e.DatabaseName),
+			semconv.DBOperationName(e.CommandName),
+			semconv.DBCollectionName(collection),
+		),
+	)
+	t.spans.Store(e.RequestID, span)
+}
+
+func (t *mongoTracer) succeeded(_ context.Context, e *event.CommandSucceededEvent) {
+	if span, ok := t.spans.LoadAndDelete(e.RequestID); ok {
+		End(span.(trace.Span), nil)
+	}
+}
+
+func (t *mongoTracer) failed(_ context.Context, e *event.CommandFailedEvent) {
+	if span, ok := t.spans.LoadAndDelete(e.RequestID); ok {
+		End(span.(trace.Span), errors.New(e.Failure))
+	}
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMalformedSignature = errors.New("malformed webhook signature")
+	ErrInvalidSignature   = errors.New("webhook signature doesn't match")
+	ErrSignatureExpired   = errors.New("webhook signature is too old")
+	ErrStopped            = errors.New("webhook dispatcher stopped before the delivery succeeded")
+)
+
+// StatusError is returned for deliveries the endpoint answered with a non 2xx status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("endpoint responded with status %d", e.StatusCode)
+}
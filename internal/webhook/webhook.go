@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/google/uuid"
+)
+
+// Store holds the endpoints registered with the admin API and the failed deliveries.
+type Store interface {
+	ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	WriteWebhookDeadLetter(ctx context.Context, deadLetter models.WebhookDeadLetter) error
+}
+
+// endpointsCacheTTL is how long the endpoints registered with the admin API are cached. Changes made
+// through this instance apply right away with InvalidateEndpoints, the ones made through other
+// instances once the cache expires.
+const endpointsCacheTTL = 30 * time.Second
+
+// Dispatcher delivers events to every subscribed endpoint. Deliveries are synchronous, so the
+// outbox keeps an event until every endpoint accepted it, rejected it or ran out of attempts.
+// Failed deliveries are moved to the dead letter store then.
+type Dispatcher struct {
+	store     Store
+	config    config.Webhooks
+	endpoints []models.WebhookEndpoint
+	client    *http.Client
+
+	// registered caches the endpoints of the store, loadedAt is zero when it has to be loaded.
+	mu         sync.Mutex
+	registered []models.WebhookEndpoint
+	loadedAt   time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type delivery struct {
+	endpoint models.WebhookEndpoint
+	event    models.Event
+	attempt  int
+}
+
+func New(store Store, conf config.Webhooks) *Dispatcher {
+	endpoints := make([]models.WebhookEndpoint, 0, len(conf.Endpoints))
+	for i, endpoint := range conf.Endpoints {
+		events := make([]models.EventType, 0, len(endpoint.Events))
+		for _, event := range endpoint.Events {
+			events = append(events, models.EventType(event))
+		}
+		endpoints = append(endpoints, models.WebhookEndpoint{
+			ID:     "config-" + strconv.Itoa(i),
+			URL:    endpoint.URL,
+			Secret: endpoint.Secret,
+			Events: events,
+		})
+	}
+
+	return &Dispatcher{
+		store:     store,
+		config:    conf,
+		endpoints: endpoints,
+		client:    &http.Client{Timeout: conf.Timeout},
+		stop:      make(chan struct{}),
+	}
+}
+
+// InvalidateEndpoints makes the next event load the registered endpoints from the store again.
+func (d *Dispatcher) InvalidateEndpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.loadedAt = time.Time{}
+}
+
+// Stop cancels the deliveries in flight, their events stay in the outbox.
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() { close(d.stop) })
+}
+
+// Publish sends the event to the subscribed endpoints, up to config.Workers at once. attempt counts
+// the publications of the event, starting at 1. Deliveries that fail with a retryable error make
+// Publish fail so the event is published again later, unless it was the last attempt: then they,
+// like deliveries the endpoint rejected, are dead lettered and Publish succeeds.
+// Endpoints that accepted the event receive it again on the next attempt.
+func (d *Dispatcher) Publish(ctx context.Context, event models.Event, attempt int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-d.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	subscribers, err := d.subscribers(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	slots := make(chan struct{}, max(d.config.Workers, 1))
+	for _, endpoint := range subscribers {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := d.deliver(ctx, delivery{endpoint: endpoint, event: event, attempt: attempt}); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err = errors.Join(errs...); err != nil {
+		select {
+		case <-d.stop:
+			return fmt.Errorf("%w: %w", ErrStopped, err)
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver returns an error if the delivery should be attempted again.
+func (d *Dispatcher) deliver(ctx context.Context, job delivery) error {
+	err := d.send(ctx, job)
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("webhook delivery to %s canceled: %w", job.endpoint.ID, err)
+	case job.attempt >= d.config.MaxAttempts || !retryable(err):
+		return d.deadLetter(job, err)
+	default:
+		slog.Warn("webhook delivery failed, retrying",
+			"endpoint", job.endpoint.ID, "event", job.event.ID, "attempt", job.attempt, "error", err.Error())
+		return fmt.Errorf("webhook delivery to %s failed: %w", job.endpoint.ID, err)
+	}
+}
+
+func (d *Dispatcher) subscribers(ctx context.Context, eventType models.EventType) ([]models.WebhookEndpoint, error) {
+	endpoints := d.endpoints
+	if d.store != nil {
+		registered, err := d.registeredEndpoints(ctx)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = slices.Concat(registered, endpoints)
+	}
+
+	subscribed := make([]models.WebhookEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribed(eventType) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	return subscribed, nil
+}
+
+func (d *Dispatcher) registeredEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.loadedAt.IsZero() && time.Since(d.loadedAt) < endpointsCacheTTL {
+		return d.registered, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+	registered, err := d.store.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	d.registered, d.loadedAt = registered, time.Now()
+	return registered, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, job delivery) error {
+	body, err := json.Marshal(job.event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", job.event.ID)
+	req.Header.Set("X-Webhook-Event", string(job.event.Type))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(job.attempt))
+	req.Header.Set(SignatureHeader, Sign(job.endpoint.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// retryable reports whether a later attempt may succeed. Client errors other than
+// timeouts and rate limiting mean the endpoint rejects the event itself.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.StatusCode >= 500 ||
+		statusErr.StatusCode == http.StatusRequestTimeout ||
+		statusErr.StatusCode == http.StatusTooManyRequests
+}
+
+// deadLetter stores the failed delivery. If that fails too, the event is published again.
+func (d *Dispatcher) deadLetter(job delivery, err error) error {
+	slog.Error("webhook delivery failed", "endpoint", job.endpoint.ID, "event", job.event.ID, "attempts", job.attempt, "error", err.Error())
+	if d.store == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+	deadLetter := models.WebhookDeadLetter{
+		ID:         uuid.NewString(),
+		EndpointID: job.endpoint.ID,
+		URL:        job.endpoint.URL,
+		Event:      job.event,
+		Attempts:   job.attempt,
+		LastError:  err.Error(),
+		FailedAt:   time.Now().UTC(),
+	}
+	if err := d.store.WriteWebhookDeadLetter(ctx, deadLetter); err != nil {
+		return fmt.Errorf("failed to write webhook dead letter: %w", err)
+	}
+	return nil
+}
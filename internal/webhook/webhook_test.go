@@ -0,0 +1,170 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/avran02/authentication/internal/config"
+	"github.com/avran02/authentication/internal/models"
+	"github.com/avran02/authentication/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "whsec"
+
+type store struct {
+	mu          sync.Mutex
+	endpoints   []models.WebhookEndpoint
+	lists       int
+	deadLetters []models.WebhookDeadLetter
+}
+
+func (s *store) ListWebhookEndpoints(context.Context) ([]models.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists++
+	return slices.Clone(s.endpoints), nil
+}
+
+func (s *store) WriteWebhookDeadLetter(_ context.Context, deadLetter models.WebhookDeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters = append(s.deadLetters, deadLetter)
+	return nil
+}
+
+func dispatcher(st *store, url string, events ...string) *webhook.Dispatcher {
+	return webhook.New(st, config.Webhooks{
+		Endpoints:   []config.WebhookEndpoint{{URL: url, Secret: secret, Events: events}},
+		MaxAttempts: 3,
+		Timeout:     time.Second,
+		Workers:     1,
+	})
+}
+
+func TestDispatcher_RetriesAndSigns(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan models.Event, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event models.Event
+		_ = json.Unmarshal(body, &event)
+		received <- event
+	}))
+	defer receiver.Close()
+
+	st := &store{}
+	d := dispatcher(st, receiver.URL)
+	event := models.Event{ID: "event123", Type: models.EventUserRegistered, UserID: "user123"}
+
+	assert.Error(t, d.Publish(context.Background(), event, 1))
+	assert.NoError(t, d.Publish(context.Background(), event, 2))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, models.EventUserRegistered, event.Type)
+		assert.Equal(t, "user123", event.UserID)
+		assert.Equal(t, "event123", event.ID)
+	default:
+		t.Fatal("event was not delivered")
+	}
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Empty(t, st.deadLetters)
+}
+
+func TestDispatcher_DeadLetters(t *testing.T) {
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	st := &store{}
+	d := dispatcher(st, receiver.URL, string(models.EventUserLoggedIn))
+
+	assert.NoError(t, d.Publish(context.Background(), models.Event{Type: models.EventUserRegistered}, 1))
+	for attempt := 1; attempt < 3; attempt++ {
+		assert.Error(t, d.Publish(context.Background(), models.Event{Type: models.EventUserLoggedIn}, attempt))
+	}
+	assert.NoError(t, d.Publish(context.Background(), models.Event{Type: models.EventUserLoggedIn}, 3))
+
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Len(t, st.deadLetters, 1)
+	assert.Equal(t, models.EventUserLoggedIn, st.deadLetters[0].Event.Type)
+	assert.Equal(t, 3, st.deadLetters[0].Attempts)
+}
+
+func TestDispatcher_StopKeepsEvent(t *testing.T) {
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	st := &store{}
+	d := dispatcher(st, receiver.URL)
+	time.AfterFunc(50*time.Millisecond, d.Stop)
+
+	err := d.Publish(context.Background(), models.Event{Type: models.EventUserLoggedIn}, 3)
+	assert.ErrorIs(t, err, webhook.ErrStopped)
+	assert.Empty(t, st.deadLetters)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	header := webhook.Sign(secret, time.Now(), body)
+
+	assert.NoError(t, webhook.Verify(secret, header, body, time.Minute))
+	assert.ErrorIs(t, webhook.Verify("other", header, body, time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify(secret, header, []byte(`{"id":"2"}`), time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify(secret, "v1=abc", body, time.Minute), webhook.ErrMalformedSignature)
+
+	old := webhook.Sign(secret, time.Now().Add(-time.Hour), body)
+	assert.ErrorIs(t, webhook.Verify(secret, old, body, time.Minute), webhook.ErrSignatureExpired)
+}
+
+func TestDispatcher_CachesRegisteredEndpoints(t *testing.T) {
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer receiver.Close()
+
+	st := &store{}
+	d := webhook.New(st, config.Webhooks{MaxAttempts: 3, Timeout: time.Second, Workers: 1})
+	event := models.Event{ID: "event123", Type: models.EventUserRegistered, UserID: "user123"}
+
+	assert.NoError(t, d.Publish(context.Background(), event, 1))
+	assert.Equal(t, int32(0), received.Load())
+
+	// a new endpoint is picked up only after the cache is invalidated
+	st.mu.Lock()
+	st.endpoints = []models.WebhookEndpoint{{ID: "1", URL: receiver.URL, Secret: secret}}
+	st.mu.Unlock()
+	assert.NoError(t, d.Publish(context.Background(), event, 1))
+	assert.Equal(t, int32(0), received.Load())
+
+	d.InvalidateEndpoints()
+	assert.NoError(t, d.Publish(context.Background(), event, 1))
+	assert.NoError(t, d.Publish(context.Background(), event, 1))
+	assert.Equal(t, int32(2), received.Load())
+	assert.Equal(t, 2, st.lists)
+}
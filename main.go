@@ -1,9 +1,11 @@
 package main
 
 import (
-	"github.com/avran02/authentication/internal/app"
+	"os"
+
+	"github.com/avran02/authentication/internal/cli"
 )
 
 func main() {
-	app.New().Run()
+	cli.Run(os.Args[1:])
 }